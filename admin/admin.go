@@ -0,0 +1,271 @@
+// Package admin provide a http api for operators to inspect and manage the running server,
+// all responses are encoded in json.
+package admin
+
+import (
+	b "barrage-server/base"
+	"barrage-server/libs/log"
+	r "barrage-server/room"
+	"barrage-server/user"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var logger = b.Log.Named("admin")
+
+// ListenAndServe open the admin api server on addr, it should only be reachable by operators.
+func ListenAndServe(addr string) {
+	logger.Infof("Admin api start, bind address: %v \n", addr)
+	if err := http.ListenAndServe(addr, newServeMux()); err != nil {
+		logger.Errorln("Admin ListenAndServe:", err)
+	}
+}
+
+// newServeMux register all api handlers.
+func newServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stats", statsHandler)
+	mux.HandleFunc("/flood", floodHandler)
+	mux.HandleFunc("/users", usersHandler)
+	mux.HandleFunc("/user/mute", muteHandler)
+	mux.HandleFunc("/room/private", privateRoomHandler)
+	mux.HandleFunc("/room/public", publicRoomHandler)
+	mux.HandleFunc("/log/level", logLevelHandler)
+	mux.HandleFunc("/log/verbose", logVerboseHandler)
+	return mux
+}
+
+// writeJSON write v into w as json.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Errorln(err)
+	}
+}
+
+// writeError write error message into w with status code.
+func writeError(w http.ResponseWriter, code int, err string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": err})
+}
+
+// parseUserID get user id from the parameter 'uid' of req, it is read from both query
+// and form body.
+func parseUserID(req *http.Request) (b.UserID, bool) {
+	uid, err := strconv.ParseUint(req.FormValue("uid"), 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return b.UserID(uid), true
+}
+
+// parseRoom get room by the query parameter 'rid' of req, it writes error into w
+// if room is not found.
+func parseRoom(w http.ResponseWriter, req *http.Request) (*r.Room, bool) {
+	rid, err := strconv.ParseUint(req.FormValue("rid"), 10, 32)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid parameter 'rid'.")
+		return nil, false
+	}
+
+	room, ok := r.GetRoom(b.RoomID(rid))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Room %d is not exist.", rid))
+		return nil, false
+	}
+	return room, true
+}
+
+// statsHandler response the lifetime stats of the player given by 'uid'.
+//
+// GET /stats?uid=<uid>
+func statsHandler(w http.ResponseWriter, req *http.Request) {
+	uid, ok := parseUserID(req)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid parameter 'uid'.")
+		return
+	}
+
+	ps, err := r.StatsStore().Stats(uid)
+	if err != nil {
+		logger.Errorln(err)
+		writeError(w, http.StatusInternalServerError, b.ErrServerError.Error())
+		return
+	}
+
+	writeJSON(w, ps)
+}
+
+// floodHandler response the counters of flood protection.
+//
+// GET /flood
+func floodHandler(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, user.FloodStats())
+}
+
+// userStatus is the status of an online user.
+type userStatus struct {
+	UID     b.UserID     `json:"uid"`
+	RID     b.RoomID     `json:"rid"`
+	Latency user.Latency `json:"latency"`
+}
+
+// usersHandler response all online users with their rooms and latencies, durations
+// are numbers of nanoseconds.
+//
+// GET /users
+func usersHandler(w http.ResponseWriter, req *http.Request) {
+	us := r.OnlineUsers()
+	statuses := make([]userStatus, 0, len(us))
+	for _, u := range us {
+		statuses = append(statuses, userStatus{UID: u.ID(), RID: u.Room(), Latency: u.Latency()})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].UID < statuses[j].UID })
+
+	writeJSON(w, statuses)
+}
+
+// muteHandler forbid the user given by 'uid' from chatting in all rooms for 'seconds',
+// 0 seconds unmutes the user.
+//
+// POST /user/mute?uid=<uid>&seconds=<seconds>
+func muteHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method should be POST.")
+		return
+	}
+	uid, ok := parseUserID(req)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid parameter 'uid'.")
+		return
+	}
+	seconds, err := strconv.ParseUint(req.FormValue("seconds"), 10, 32)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid parameter 'seconds'.")
+		return
+	}
+
+	if seconds == 0 {
+		r.UnmuteUser(uid)
+		logger.Infof("User %d is unmuted. \n", uid)
+	} else {
+		d := time.Duration(seconds) * time.Second
+		r.MuteUser(uid, d)
+		logger.Infof("User %d is muted for %v. \n", uid, d)
+	}
+	writeJSON(w, map[string]interface{}{"uid": uid, "seconds": seconds})
+}
+
+// privateRoomHandler make a room private with an optional password, if 'invite' is
+// true, a new invite code is generated and responsed.
+//
+// POST /room/private?rid=<rid>&password=<password>&invite=<true|false>
+func privateRoomHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method should be POST.")
+		return
+	}
+	room, ok := parseRoom(w, req)
+	if !ok {
+		return
+	}
+
+	room.SetPrivate(req.FormValue("password"))
+
+	resp := map[string]interface{}{"rid": room.ID()}
+	if req.FormValue("invite") == "true" {
+		code, err := room.NewInviteCode()
+		if err != nil {
+			logger.Errorln(err)
+			writeError(w, http.StatusInternalServerError, b.ErrServerError.Error())
+			return
+		}
+		resp["invite_code"] = code
+	}
+
+	writeJSON(w, resp)
+}
+
+// publicRoomHandler make a room public, its password and invite code are cleared.
+//
+// POST /room/public?rid=<rid>
+func publicRoomHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method should be POST.")
+		return
+	}
+	room, ok := parseRoom(w, req)
+	if !ok {
+		return
+	}
+
+	room.SetPublic()
+	writeJSON(w, map[string]interface{}{"rid": room.ID()})
+}
+
+// rootLogger is the name of root logger in api.
+const rootLogger = "root"
+
+// logLevelHandler response levels of all loggers, or set the level of logger given by
+// 'name' if method is POST. levels are debug, info, warn, error, panic and fatal.
+//
+// GET /log/level
+// POST /log/level?name=<root|room|user|...>&level=<level>
+func logLevelHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodPost {
+		level, err := log.ParseLevel(req.FormValue("level"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid parameter 'level'.")
+			return
+		}
+		name := req.FormValue("name")
+		if name == rootLogger {
+			name = ""
+		}
+		if err := b.Log.SetLevel(name, level); err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		logger.Infof("Level of logger %s is set to %s. \n", req.FormValue("name"), log.LevelName(level))
+	}
+
+	levels := make(map[string]string)
+	for name, level := range b.Log.Levels() {
+		if name == "" {
+			name = rootLogger
+		}
+		levels[name] = log.LevelName(level)
+	}
+	writeJSON(w, levels)
+}
+
+// logVerboseHandler print logs of all levels about the user given by 'uid' for
+// 'seconds', 0 seconds stops it.
+//
+// POST /log/verbose?uid=<uid>&seconds=<seconds>
+func logVerboseHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method should be POST.")
+		return
+	}
+	uid, ok := parseUserID(req)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid parameter 'uid'.")
+		return
+	}
+	seconds, err := strconv.ParseUint(req.FormValue("seconds"), 10, 32)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid parameter 'seconds'.")
+		return
+	}
+
+	d := time.Duration(seconds) * time.Second
+	b.Log.SetVerbose("uid", uid, d)
+	logger.Infof("Verbose logs of user %d are enabled for %v. \n", uid, d)
+	writeJSON(w, map[string]interface{}{"uid": uid, "seconds": seconds})
+}
@@ -0,0 +1,198 @@
+package admin
+
+import (
+	b "barrage-server/base"
+	"barrage-server/libs/log"
+	r "barrage-server/room"
+	"barrage-server/store"
+	"barrage-server/user"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// TestStatsHandler ...
+func TestStatsHandler(t *testing.T) {
+	r.SetStatsStore(store.NewMemoryStore())
+	r.StatsStore().AddStats(10, store.PlayerStats{Matches: 2, Kills: 3})
+
+	server := httptest.NewServer(newServeMux())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/stats?uid=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Status code is wrong, hope %d, get %d.", http.StatusOK, resp.StatusCode)
+	}
+	var ps store.PlayerStats
+	if err := json.NewDecoder(resp.Body).Decode(&ps); err != nil {
+		t.Error(err)
+	}
+	if ps.UID != 10 || ps.Matches != 2 || ps.Kills != 3 {
+		t.Errorf("Stats of user 10 is wrong, get %+v.", ps)
+	}
+
+	resp, err = http.Get(server.URL + "/stats?uid=abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status code is wrong, hope %d, get %d.", http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+// TestFloodHandler ...
+func TestFloodHandler(t *testing.T) {
+	server := httptest.NewServer(newServeMux())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/flood")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var fc user.FloodCounters
+	if err := json.NewDecoder(resp.Body).Decode(&fc); err != nil {
+		t.Error(err)
+	}
+	if fc != user.FloodStats() {
+		t.Errorf("Flood counters are wrong, hope %+v, get %+v.", user.FloodStats(), fc)
+	}
+}
+
+// TestUsersHandler ...
+func TestUsersHandler(t *testing.T) {
+	server := httptest.NewServer(newServeMux())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var statuses []userStatus
+	if err := json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		t.Error(err)
+	}
+	if n := len(statuses); n != len(r.OnlineUsers()) {
+		t.Errorf("Number of users is wrong, hope %d, get %d.", len(r.OnlineUsers()), n)
+	}
+}
+
+// TestPrivateRoomHandler ...
+func TestPrivateRoomHandler(t *testing.T) {
+	server := httptest.NewServer(newServeMux())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/room/private?rid=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Status code is wrong, hope %d, get %d.", http.StatusMethodNotAllowed, resp.StatusCode)
+	}
+
+	resp, err = http.Post(server.URL+"/room/private?rid=abc", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status code is wrong, hope %d, get %d.", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	// hall is not open in this test, so no room exists.
+	resp, err = http.Post(server.URL+"/room/private?rid=404", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Status code is wrong, hope %d, get %d.", http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+// TestLogLevelHandler ...
+func TestLogLevelHandler(t *testing.T) {
+	server := httptest.NewServer(newServeMux())
+	defer server.Close()
+
+	resp, err := http.PostForm(server.URL+"/log/level", url.Values{"name": {"admin"}, "level": {"debug"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	defer b.Log.SetLevel("admin", log.InfoLevel)
+
+	var levels map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&levels); err != nil {
+		t.Error(err)
+	}
+	if levels["admin"] != "debug" || levels["root"] == "" {
+		t.Errorf("Levels of loggers are wrong, get %v.", levels)
+	}
+
+	resp, err = http.PostForm(server.URL+"/log/level", url.Values{"name": {"unknown"}, "level": {"debug"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Status code is wrong, hope %d, get %d.", http.StatusNotFound, resp.StatusCode)
+	}
+
+	resp, err = http.PostForm(server.URL+"/log/level", url.Values{"name": {"admin"}, "level": {"loud"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status code is wrong, hope %d, get %d.", http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+// TestMuteHandler ...
+func TestMuteHandler(t *testing.T) {
+	server := httptest.NewServer(newServeMux())
+	defer server.Close()
+
+	// uid is read from query or form body.
+	for _, seconds := range []string{"60", "0"} {
+		resp, err := http.PostForm(server.URL+"/user/mute", url.Values{"uid": {"7"}, "seconds": {seconds}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Status code is wrong, hope %d, get %d.", http.StatusOK, resp.StatusCode)
+		}
+	}
+
+	resp, err := http.PostForm(server.URL+"/user/mute?uid=7", url.Values{"seconds": {"-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status code is wrong, hope %d, get %d.", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	resp, err = http.Get(server.URL + "/user/mute?uid=7&seconds=60")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Status code is wrong, hope %d, get %d.", http.StatusMethodNotAllowed, resp.StatusCode)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"barrage-server/libs/bufbo"
 	"errors"
 	"fmt"
-	"math"
 )
 
 var (
@@ -31,6 +30,20 @@ const (
 	Disappear
 )
 
+var stateNames = map[State]string{
+	Alive:     "alive",
+	Dead:      "dead",
+	Disappear: "disappear",
+}
+
+// String return the name of state, unknown state is shown as its value.
+func (s State) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("state(%d)", uint8(s))
+}
+
 // Type is the type of ball
 type Type uint8
 
@@ -45,6 +58,21 @@ const (
 	Food
 )
 
+var typeNames = map[Type]string{
+	AirPlane: "airplane",
+	Block:    "block",
+	Bullet:   "bullet",
+	Food:     "food",
+}
+
+// String return the name of type, unknown type is shown as its value.
+func (t Type) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("type(%d)", uint8(t))
+}
+
 const (
 	ballBaseSize = 28
 )
@@ -77,7 +105,9 @@ type Ball interface {
 	// Damage() b.Damage
 	// SetHP(hp)
 
-	// // Position() (x, y uint16)
+	// Position return the current location of ball in playground.
+	Position() (x, y uint16)
+	Radius() uint16
 	// IsDisappear() bool
 }
 
@@ -96,9 +126,7 @@ type ball struct {
 	location  location
 }
 
-// NewBallFromBytes creates ball from bytes,
-//
-// The caller of this function should deal with all err and panic for index out of range!
+// NewBallFromBytes creates ball from bytes, it fails if bytes are not enough.
 func NewBallFromBytes(b []byte) (Ball, error) {
 	newBall := &ball{}
 	if err := newBall.UnmarshalBinary(b); err != nil {
@@ -130,8 +158,8 @@ func (bl *ball) ID() b.BallID {
 	return bl.id
 }
 
-func (bl *ball) Radius() radius {
-	return bl.radius
+func (bl *ball) Radius() uint16 {
+	return uint16(bl.radius)
 }
 
 func (bl *ball) HP() hp {
@@ -146,9 +174,9 @@ func (bl *ball) SetHP(HP hp) {
 	bl.hp = HP
 }
 
-// func (bl *ball) Position() (x, y uint16) {
-// 	return bl.location.x, bl.location.y
-// }
+func (bl *ball) Position() (x, y uint16) {
+	return bl.location.x, bl.location.y
+}
 
 func (bl *ball) IsDisappear() bool {
 	if bl.state == Disappear {
@@ -158,6 +186,14 @@ func (bl *ball) IsDisappear() bool {
 	return false
 }
 
+// String show all fields of ball in one line, it is used for debugging.
+func (bl *ball) String() string {
+	return fmt.Sprintf("uid=%d id=%d nickname=%q type=%v hp=%d damage=%d role=%d special=%d "+
+		"radius=%d attackDir=%v state=%v location=(%d,%d)",
+		bl.uid, bl.id, bl.nickname, bl.bType, bl.hp, bl.damage, bl.role, bl.special,
+		bl.radius, float32(bl.attackDir), bl.state, bl.location.x, bl.location.y)
+}
+
 func (bl *ball) Size() int {
 	return ballBaseSize + len(bl.nickname)
 }
@@ -174,11 +210,10 @@ func (bl *ball) MarshalBinary() ([]byte, error) {
 	bw.PutUint16(uint16(bl.id))
 
 	nicknameLen := len(bl.nickname)
-	if nicknameLen > math.MaxUint8 {
-		return nil, fmt.Errorf("Nickname is too long, hope 255, get %d.", nicknameLen)
+	if nicknameLen > b.InfoStringMaxLength {
+		return nil, fmt.Errorf("Nickname is too long, hope at most %d, get %d.", b.InfoStringMaxLength, nicknameLen)
 	}
-	bw.PutUint8(uint8(nicknameLen))
-	bw.PutStr(bl.nickname)
+	bw.PutPrefixedStr(bl.nickname, bufbo.PrefixUint8)
 	bw.PutUint8(uint8(bl.bType))
 	bw.PutUint8(uint8(bl.hp))
 	bw.PutUint8(uint8(bl.damage))
@@ -195,12 +230,15 @@ func (bl *ball) MarshalBinary() ([]byte, error) {
 }
 
 func (bl *ball) UnmarshalBinary(data []byte) error {
-	br := bufbo.NewBEBytesReader(data)
+	br := bufbo.NewBECheckedReader(data)
 
 	bl.uid = b.UserID(br.Uint32())
 	bl.uid = b.UserID(br.Uint32())
 	bl.id = b.BallID(br.Uint16())
-	bl.nickname = br.Str(int(br.Uint8()))
+	bl.nickname = br.PrefixedStr(bufbo.PrefixUint8)
+	if nicknameLen := len(bl.nickname); nicknameLen > b.InfoStringMaxLength {
+		return fmt.Errorf("Nickname is too long, hope at most %d, get %d.", b.InfoStringMaxLength, nicknameLen)
+	}
 	bl.bType = Type(br.Uint8())
 	bl.hp = hp(br.Uint8())
 	bl.damage = b.Damage(br.Uint8())
@@ -212,5 +250,5 @@ func (bl *ball) UnmarshalBinary(data []byte) error {
 	bl.location.x = br.Uint16()
 	bl.location.y = br.Uint16()
 
-	return nil
+	return br.Err()
 }
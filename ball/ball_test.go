@@ -1,8 +1,8 @@
 package ball
 
 import (
+	"barrage-server/libs/bufbo"
 	"fmt"
-	"strings"
 	"testing"
 )
 
@@ -100,11 +100,19 @@ func TestNewBallFromBytes(t *testing.T) {
 		t.Error(err)
 	}
 
-	defer func() {
-		t.Log("Running panic testing.")
-		if err := recover(); !strings.Contains(err.(error).Error(), "index out of range") {
-			t.Errorf("Hope get panic with error 'index out of range', but get '%v'.", err.(error))
-		}
-	}()
-	NewBallFromBytes(b[31:])
+	if _, err := NewBallFromBytes(b[31:]); err != bufbo.ErrNotEnough {
+		t.Errorf("Hope get error '%v', but get '%v'.", bufbo.ErrNotEnough, err)
+	}
+}
+
+func TestBallString(t *testing.T) {
+	hope := `uid=1234 id=0 nickname="9999 9999" type=airplane hp=100 damage=10 role=1 special=99 ` +
+		`radius=10 attackDir=400 state=alive location=(99,99)`
+	if s := fmt.Sprint(generateBall()); s != hope {
+		t.Errorf("String of ball is wrong, hope %s, get %s.", hope, s)
+	}
+
+	if s := State(9).String(); s != "state(9)" {
+		t.Errorf("String of unknown state is wrong, hope state(9), get %s.", s)
+	}
 }
@@ -21,6 +21,9 @@ type ImageID uint8
 // Damage is damage of ball.
 type Damage uint8
 
+// GameMode is the rule of game in a room.
+type GameMode uint8
+
 // FullBallID is the full id of ball, it consists of UserID and BallID
 type FullBallID struct {
 	UID UserID
@@ -42,6 +45,15 @@ const (
 	SysID = 0
 )
 
+const (
+	// game modes
+
+	// FreeForAll every user fights for himself.
+	FreeForAll = GameMode(iota)
+	// TeamBattle users are divided into teams and fight together.
+	TeamBattle
+)
+
 const (
 	// environment
 
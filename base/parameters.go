@@ -21,3 +21,125 @@ var OpenRoomIDs = []RoomID{1}
 
 // RoomBoardCastDuration the duration between two boardcast of the room
 var RoomBoardCastDuration = time.Millisecond * 40
+
+// HallLoopDuration is the duration between two pushes of room list in hall.
+var HallLoopDuration = time.Second
+
+// RoomGameMode is the default game mode of rooms.
+var RoomGameMode = FreeForAll
+
+// RoomFriendlyFire is whether users in the same team can hurt each other by default.
+var RoomFriendlyFire = false
+
+// RoomBackground is the default background image of rooms.
+var RoomBackground = ImageID(0)
+
+// ChatMessageMaxLength limit the number of bytes of a chat message.
+var ChatMessageMaxLength = 120
+
+// ChatRate is the number of chat messages a user can send per second.
+var ChatRate = 0.5
+
+// ChatBurst is the number of chat messages a user can send in a burst.
+var ChatBurst = 3
+
+// ChatHistorySize is the number of recent chat messages sent to user who just joined room.
+var ChatHistorySize = 20
+
+// FloodWarnThreshold is the number of over-limit messages in FloodWindow before warning user.
+var FloodWarnThreshold = 10
+
+// FloodDisconnectThreshold is the number of over-limit messages in FloodWindow before
+// disconnecting user.
+var FloodDisconnectThreshold = 50
+
+// FloodWindow is the duration over-limit messages are counted in.
+var FloodWindow = time.Second * 10
+
+// InfoChanSize is the capacity of the info channel of hall and every room, playground
+// info from users is dropped when the channel is full.
+var InfoChanSize = 256
+
+// InfoUploadTimeout is the longest duration to wait for a full info channel before
+// refusing info other than playground info, such as connect and disconnect.
+var InfoUploadTimeout = time.Millisecond * 100
+
+// OutboundQueueSize limit the number of control messages waiting to be sent to a user.
+var OutboundQueueSize = 64
+
+// OutboundLagLimit is the longest duration a user can stay behind the outbound queue
+// before being disconnected.
+var OutboundLagLimit = time.Second * 3
+
+// HeartbeatInterval is the duration between two heartbeats sent by frontend.
+var HeartbeatInterval = time.Second * 5
+
+// HeartbeatTimeout is the longest duration without any message from frontend before
+// the connection is treated as dead, it should be a few times of HeartbeatInterval.
+var HeartbeatTimeout = time.Second * 15
+
+// WriteTimeout is the longest duration to write a message to frontend.
+var WriteTimeout = time.Second * 2
+
+// PingInterval is the duration between two pings sent to a user.
+var PingInterval = time.Second * 5
+
+// MaxRewind is the longest duration playground rewinds to when validating a collision
+// reported by a lagging user.
+var MaxRewind = time.Millisecond * 200
+
+// CollisionTolerance is the distance two balls can be apart while still being treated
+// as collided.
+var CollisionTolerance = 10
+
+// MatchSkillRange is the largest difference between the skill of a user and the average
+// skill of a room when matching the user into the room.
+var MatchSkillRange = 300
+
+// MessageMaxSize limit the number of bytes of a message, larger frames are discarded
+// by websocket before being read.
+var MessageMaxSize = 256 << 10
+
+// InfoListMaxLength limit the number of items of a list in a message, such as balls,
+// collisions and disappears of playground info.
+var InfoListMaxLength = 4096
+
+// InfoStringMaxLength limit the number of bytes of a string in a message, such as
+// nickname, room name and chat message, it is checked when both encoding and decoding.
+// it should not be less than ChatMessageMaxLength, and not be greater than 255 because
+// strings are prefixed by Uint8 length.
+var InfoStringMaxLength = 128
+
+// MatchRegions is the regions accepted by quick play, rooms created for quick play are
+// tagged with one of them. empty region, which means any region, is always accepted.
+var MatchRegions = []string{"asia", "eu", "us"}
+
+// MaxRooms limit the number of rooms in hall, hall stops creating room for quick play
+// when reaching the limit.
+var MaxRooms = 64
+
+// IdleWarnTimeout is the duration without input before warning a user in room.
+var IdleWarnTimeout = time.Second * 30
+
+// IdleEvictTimeout is the duration without input before moving a user from room to
+// hall, it should be longer than IdleWarnTimeout.
+var IdleEvictTimeout = time.Second * 60
+
+// HallIdleTimeout is the duration without any message before disconnecting a user
+// in hall.
+var HallIdleTimeout = time.Minute * 10
+
+// LogBufferSize is the number of logs waiting to be written in async mode, the oldest
+// log is dropped when it is full.
+var LogBufferSize = 4096
+
+// LogDedupWindow is the duration the same log from the same line is counted instead
+// of written.
+var LogDedupWindow = time.Second * 5
+
+// LogSampleFirst is the number of logs written per line per second, after which only
+// every LogSampleThereafter-th log is written.
+var LogSampleFirst = 100
+
+// LogSampleThereafter see LogSampleFirst.
+var LogSampleThereafter = 100
@@ -0,0 +1,305 @@
+// Package client provide a client of barrage server, it is shared by testClient, bots
+// and load tests.
+//
+// A Client is created by Dial, it keeps the connection alive with heartbeats, replies
+// pings of server for measuring latency, and delivers decoded info packages by
+// InfoPkgs or Config.Handler.
+package client
+
+import (
+	b "barrage-server/base"
+	m "barrage-server/message"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"golang.org/x/net/websocket"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	defaultOrigin     = "http://localhost/"
+	defaultBufferSize = 64
+)
+
+var (
+	// ErrClosed is returned by sending on a closed client.
+	ErrClosed = errors.New("Client is closed.")
+	// errNoUserID is returned by Dial if the first message of server is not user id.
+	errNoUserID = errors.New("First message from server is not user id.")
+)
+
+// Config is the options of a client, zero values are replaced by defaults.
+type Config struct {
+	// Origin is the origin of websocket handshake, it is http://localhost/ by default.
+	Origin string
+	// HeartbeatInterval is the duration between two heartbeats, b.HeartbeatInterval by default.
+	HeartbeatInterval time.Duration
+	// BufferSize is the capacity of InfoPkgs channel, 64 by default.
+	BufferSize int
+
+	// Handler is called with every decoded info package and the message it comes from
+	// in the receive loop, InfoPkgs is not used when Handler is set.
+	Handler func(msg m.Message, ipkg m.InfoPkg)
+	// OnError is called with errors of decoding messages and sending heartbeats,
+	// they are dropped if it is nil.
+	OnError func(err error)
+}
+
+// Client is a connection to barrage server, its methods are goroutine safe.
+type Client struct {
+	conf Config
+	wc   *websocket.Conn
+	uid  b.UserID
+
+	ipkgs chan m.InfoPkg
+	done  chan struct{}
+	// closed is closed by Close, it stops the receive loop waiting for InfoPkgs drained.
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	errM sync.Mutex
+	err  error
+	// closing is true after Close is called, the error of receiving is expected then.
+	closing bool
+}
+
+// Dial connect to the server at url such as ws://localhost:2334/test with default config.
+func Dial(url string) (*Client, error) {
+	return DialConfig(url, Config{})
+}
+
+// DialConfig connect to the server at url, then wait for user id sent by server.
+func DialConfig(url string, conf Config) (*Client, error) {
+	if conf.Origin == "" {
+		conf.Origin = defaultOrigin
+	}
+	if conf.HeartbeatInterval <= 0 {
+		conf.HeartbeatInterval = b.HeartbeatInterval
+	}
+	if conf.BufferSize <= 0 {
+		conf.BufferSize = defaultBufferSize
+	}
+
+	wc, err := websocket.Dial(url, "", conf.Origin)
+	if err != nil {
+		return nil, err
+	}
+
+	uid, err := receiveUserID(wc)
+	if err != nil {
+		wc.Close()
+		return nil, err
+	}
+
+	c := &Client{
+		conf:   conf,
+		wc:     wc,
+		uid:    uid,
+		ipkgs:  make(chan m.InfoPkg, conf.BufferSize),
+		done:   make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+	go c.receive()
+	go c.keepAlive()
+
+	return c, nil
+}
+
+// receiveUserID receive the first message from server, it should be MsgRandomUserID.
+func receiveUserID(wc *websocket.Conn) (b.UserID, error) {
+	var bs []byte
+	if err := websocket.Message.Receive(wc, &bs); err != nil {
+		return 0, err
+	}
+
+	msg, err := m.NewMessageFromBytes(bs)
+	if err != nil {
+		return 0, err
+	}
+	if msg.Type() != m.MsgRandomUserID || len(msg.Body()) != 4 {
+		return 0, errNoUserID
+	}
+
+	return b.UserID(binary.BigEndian.Uint32(msg.Body())), nil
+}
+
+// UID return the user id given by server.
+func (c *Client) UID() b.UserID {
+	return c.uid
+}
+
+// InfoPkgs return the channel of received info packages, it is closed when the
+// connection is over. the receive loop blocks while the channel is full, so it
+// should be drained by the caller until Close is called.
+func (c *Client) InfoPkgs() <-chan m.InfoPkg {
+	return c.ipkgs
+}
+
+// Done return a channel closed when the connection is over.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err return the error which breaks off the connection, it is nil if the connection
+// is closed by Close or server normally.
+func (c *Client) Err() error {
+	c.errM.Lock()
+	defer c.errM.Unlock()
+
+	return c.err
+}
+
+// Close close the connection, InfoPkgs and Done are closed after the receive loop exits.
+func (c *Client) Close() error {
+	c.errM.Lock()
+	c.closing = true
+	c.errM.Unlock()
+
+	c.closeOnce.Do(func() { close(c.closed) })
+	return c.wc.Close()
+}
+
+// Send send ipkg to server.
+func (c *Client) Send(ipkg m.InfoPkg) error {
+	msg, err := m.NewMessageFromInfoPkg(ipkg)
+	if err != nil {
+		return err
+	}
+
+	return c.sendMessage(msg)
+}
+
+// sendMessage ...
+func (c *Client) sendMessage(msg m.Message) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+
+	bs, err := msg.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return websocket.Message.Send(c.wc, bs)
+}
+
+// JoinRoom join room rid, credential is the password or invite code of private room,
+// it is empty for public room. server replies ConnectedInfo on success.
+func (c *Client) JoinRoom(rid b.RoomID, credential string) error {
+	return c.Send(&m.ConnectInfo{
+		UID:        c.uid,
+		RID:        rid,
+		Credential: credential,
+	})
+}
+
+// Leave left room rid.
+func (c *Client) Leave(rid b.RoomID) error {
+	return c.Send(&m.DisconnectInfo{
+		UID: c.uid,
+		RID: rid,
+	})
+}
+
+// QueryRooms query visible rooms, server pushes RoomListInfo when rooms change if
+// subscribe is true.
+func (c *Client) QueryRooms(subscribe bool) error {
+	return c.Send(&m.RoomListQueryInfo{
+		UID:       c.uid,
+		Subscribe: subscribe,
+	})
+}
+
+// SendSelfInfo send balls of user to server, Sender of pi is set to the user id.
+func (c *Client) SendSelfInfo(pi *m.PlaygroundInfo) error {
+	pi.Sender = c.uid
+	bs, err := pi.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	return c.sendMessage(m.NewMessage(m.MsgUserSelf, bs))
+}
+
+// receive decode messages from server until the connection is over.
+func (c *Client) receive() {
+	defer func() {
+		c.wc.Close()
+		close(c.done)
+		close(c.ipkgs)
+	}()
+
+	var bs []byte
+	for {
+		if err := websocket.Message.Receive(c.wc, &bs); err != nil {
+			if err != io.EOF {
+				c.setErr(err)
+			}
+			return
+		}
+
+		msg, err := m.NewMessageFromBytes(bs)
+		if err != nil {
+			c.reportError(err)
+			continue
+		}
+		ipkg, err := m.NewInfoPkgFromMsg(msg)
+		if err != nil {
+			c.reportError(fmt.Errorf("Decode %v: %v", msg.Type(), err))
+			continue
+		}
+
+		// reply ping immediately for measuring latency.
+		if pi, ok := ipkg.(*m.PingInfo); ok {
+			if err := c.Send(&m.PongInfo{Seq: pi.Seq}); err != nil {
+				c.reportError(err)
+			}
+		}
+
+		if c.conf.Handler != nil {
+			c.conf.Handler(msg, ipkg)
+			continue
+		}
+		select {
+		case c.ipkgs <- ipkg:
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+// keepAlive send heartbeats until the connection is over.
+func (c *Client) keepAlive() {
+	ticker := time.NewTicker(c.conf.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.Send(&m.HeartbeatInfo{}); err != nil && err != ErrClosed {
+				c.reportError(err)
+			}
+		}
+	}
+}
+
+// setErr keep the error breaking off the connection unless it is closed by Close.
+func (c *Client) setErr(err error) {
+	c.errM.Lock()
+	defer c.errM.Unlock()
+
+	if !c.closing {
+		c.err = err
+	}
+}
+
+func (c *Client) reportError(err error) {
+	if c.conf.OnError != nil {
+		c.conf.OnError(err)
+	}
+}
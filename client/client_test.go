@@ -0,0 +1,187 @@
+package client
+
+import (
+	b "barrage-server/base"
+	m "barrage-server/message"
+	"encoding/binary"
+	"golang.org/x/net/websocket"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testUID = 2333
+
+// newTestServer start a server sending user id first, then received info packages are
+// sent to the returned channel, and info packages from send are sent to client.
+func newTestServer(t *testing.T) (url string, received <-chan m.InfoPkg, send chan<- m.InfoPkg, stop func()) {
+	recvc := make(chan m.InfoPkg, 16)
+	sendc := make(chan m.InfoPkg, 16)
+
+	s := httptest.NewServer(websocket.Handler(func(wc *websocket.Conn) {
+		bs := make([]byte, 4)
+		binary.BigEndian.PutUint32(bs, testUID)
+		uidMsg, _ := m.NewMessage(m.MsgRandomUserID, bs).MarshalBinary()
+		if err := websocket.Message.Send(wc, uidMsg); err != nil {
+			t.Error(err)
+			return
+		}
+
+		go func() {
+			for ipkg := range sendc {
+				msg, _ := m.NewMessageFromInfoPkg(ipkg)
+				bs, _ := msg.MarshalBinary()
+				websocket.Message.Send(wc, bs)
+			}
+		}()
+
+		var data []byte
+		for websocket.Message.Receive(wc, &data) == nil {
+			msg, err := m.NewMessageFromBytes(data)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			ipkg, err := m.NewInfoPkgFromMsg(msg)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			recvc <- ipkg
+		}
+	}))
+
+	return "ws" + strings.TrimPrefix(s.URL, "http"), recvc, sendc, func() {
+		close(sendc)
+		s.Close()
+	}
+}
+
+// receiveType return the first received info package of type t.
+func receiveType(t *testing.T, c <-chan m.InfoPkg, it m.InfoType) m.InfoPkg {
+	timeout := time.After(time.Second * 2)
+	for {
+		select {
+		case ipkg, ok := <-c:
+			if !ok {
+				t.Fatalf("Channel is closed before receiving type %d.", it)
+			}
+			if ipkg.Type() == it {
+				return ipkg
+			}
+		case <-timeout:
+			t.Fatalf("Timeout for receiving type %d.", it)
+		}
+	}
+}
+
+func TestClientSendAndReceive(t *testing.T) {
+	url, received, send, closeServer := newTestServer(t)
+	defer closeServer()
+
+	c, err := DialConfig(url, Config{HeartbeatInterval: time.Millisecond * 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.UID() != testUID {
+		t.Errorf("UID is wrong, hope %d, get %d.", testUID, c.UID())
+	}
+
+	if err := c.JoinRoom(7, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	ci := receiveType(t, received, m.InfoConnect).(*m.ConnectInfo)
+	if ci.UID != testUID || ci.RID != 7 || ci.Credential != "secret" {
+		t.Errorf("ConnectInfo is wrong, get %+v.", ci)
+	}
+
+	receiveType(t, received, m.InfoHeartbeat)
+
+	// ping is replied by client, and also delivered.
+	send <- &m.PingInfo{Seq: 9}
+	if pi := receiveType(t, received, m.InfoPong).(*m.PongInfo); pi.Seq != 9 {
+		t.Errorf("Seq of pong is wrong, hope 9, get %d.", pi.Seq)
+	}
+	receiveType(t, c.InfoPkgs(), m.InfoPing)
+
+	send <- &m.ConnectedInfo{UID: testUID, RID: 7, Name: "room"}
+	if ci := receiveType(t, c.InfoPkgs(), m.InfoConnected).(*m.ConnectedInfo); ci.Name != "room" {
+		t.Errorf("Name of room is wrong, hope room, get %s.", ci.Name)
+	}
+
+	c.Close()
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Client is not done after closed.")
+	}
+	if err := c.Err(); err != nil {
+		t.Errorf("Err should be nil after closed, get %v.", err)
+	}
+	if err := c.Leave(7); err != ErrClosed {
+		t.Errorf("Error of sending after closed is wrong, hope %v, get %v.", ErrClosed, err)
+	}
+}
+
+func TestClientHandler(t *testing.T) {
+	url, received, send, closeServer := newTestServer(t)
+	defer closeServer()
+
+	handled := make(chan m.Message, 1)
+	c, err := DialConfig(url, Config{
+		Handler: func(msg m.Message, ipkg m.InfoPkg) {
+			handled <- msg
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	send <- &m.ChatInfo{UID: 1, Message: "hi"}
+	select {
+	case msg := <-handled:
+		if msg.Type() != m.MsgChat {
+			t.Errorf("Type of message is wrong, hope %v, get %v.", m.MsgChat, msg.Type())
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatal("Handler is not called.")
+	}
+
+	pi := &m.PlaygroundInfo{
+		NewBalls:      &m.BallsInfo{},
+		Displacements: &m.BallsInfo{},
+		Collisions:    &m.CollisionsInfo{},
+		Disappears:    &m.DisappearsInfo{IDs: []b.BallID{1}},
+	}
+	if err := c.SendSelfInfo(pi); err != nil {
+		t.Fatal(err)
+	}
+	if pi := receiveType(t, received, m.InfoPlayground).(*m.PlaygroundInfo); len(pi.Disappears.IDs) != 1 {
+		t.Errorf("Number of disappears is wrong, hope 1, get %d.", len(pi.Disappears.IDs))
+	}
+}
+
+func TestClientCloseWithoutDraining(t *testing.T) {
+	url, _, send, closeServer := newTestServer(t)
+	defer closeServer()
+
+	c, err := DialConfig(url, Config{BufferSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nobody drains InfoPkgs, so the receive loop blocks on the second one.
+	for i := 0; i < 3; i++ {
+		send <- &m.ChatInfo{UID: 1, Message: "hi"}
+	}
+	time.Sleep(time.Millisecond * 50)
+
+	c.Close()
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Client is not done after closed.")
+	}
+}
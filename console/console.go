@@ -0,0 +1,287 @@
+// Package console provide a command line for operators to manage the running server,
+// it listens on a unix domain socket, use `socat - UNIX-CONNECT:<path>` to connect it.
+package console
+
+import (
+	b "barrage-server/base"
+	"barrage-server/libs/cmdface"
+	"barrage-server/libs/log"
+	r "barrage-server/room"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var logger = b.Log.Named("console")
+
+const prompt = "barrage> "
+
+// ListenAndServe open the console on the unix domain socket path, the stale socket file
+// is removed first, only the owner of process can connect it.
+func ListenAndServe(path string) {
+	if err := removeStaleSocket(path); err != nil {
+		logger.Errorln("Console Remove:", err)
+		return
+	}
+	l, err := listenUnix(path)
+	if err != nil {
+		logger.Errorln("Console Listen:", err)
+		return
+	}
+	defer l.Close()
+
+	if err := os.Chmod(path, 0600); err != nil {
+		logger.Errorln("Console Chmod:", err)
+		return
+	}
+
+	logger.Infof("Console start, bind address: %v \n", path)
+	if err := cmdface.Serve(l, prompt, setupCommands); err != nil {
+		logger.Errorln("Console Serve:", err)
+	}
+}
+
+// removeStaleSocket remove the socket left at path by last run, any other file at path
+// is kept and an error is returned.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket.", path)
+	}
+	return os.Remove(path)
+}
+
+// setupCommands register all commands for console c.
+func setupCommands(c *cmdface.Console) {
+	c.AddCommand(
+		"rooms",
+		"show all rooms",
+		func(params []string) { roomsFunc(c, params) })
+	c.AddCommand(
+		"users",
+		"show all online users",
+		func(params []string) { usersFunc(c, params) })
+	c.AddCommand(
+		"kick",
+		"<uid> [reason], disconnect a user",
+		func(params []string) { kickFunc(c, params) })
+	c.AddCommand(
+		"mute",
+		"<uid> <duration>, forbid a user from chatting in all rooms for duration such as 10m",
+		func(params []string) { muteFunc(c, params) })
+	c.AddCommand(
+		"unmute",
+		"<uid>, allow a muted user to chat again",
+		func(params []string) { unmuteFunc(c, params) })
+	c.AddCommand(
+		"say",
+		"<rid|all> <message>, send a chat message from server to a room or all users",
+		func(params []string) { sayFunc(c, params) })
+	c.AddCommand(
+		"close-room",
+		"<rid>, close a room and move its users back to hall",
+		func(params []string) { closeRoomFunc(c, params) })
+	c.AddCommand(
+		"loglevel",
+		"[<name> <level>], show levels of loggers or set the level of a logger, name of root logger is 'root'",
+		func(params []string) { logLevelFunc(c, params) })
+	c.AddCommand(
+		"verbose",
+		"<uid> <seconds>, print all logs of a user for seconds, 0 stops it",
+		func(params []string) { verboseFunc(c, params) })
+}
+
+// parseID parse a user id or room id.
+func parseID(s string) (uint32, error) {
+	id, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid id '%s'.", s)
+	}
+	return uint32(id), nil
+}
+
+func roomsFunc(c *cmdface.Console, params []string) {
+	c.Show("rid\tname\tusers\tmode\tprivate\n")
+	for _, room := range r.Rooms() {
+		s := room.Settings()
+		c.Show(fmt.Sprintf("%d\t%s\t%d/%d\t%d\t%v\n",
+			room.ID(), s.Name, room.Count(), s.MembersLimit, s.Mode, room.IsPrivate()))
+	}
+}
+
+func usersFunc(c *cmdface.Console, params []string) {
+	us := r.OnlineUsers()
+	sort.Slice(us, func(i, j int) bool { return us[i].ID() < us[j].ID() })
+
+	c.Show("uid\trid\trtt\n")
+	for _, u := range us {
+		c.Show(fmt.Sprintf("%d\t%d\t%v\n", u.ID(), u.Room(), u.Latency().RTT))
+	}
+}
+
+func kickFunc(c *cmdface.Console, params []string) {
+	if len(params) < 1 {
+		c.Show("Usage: kick <uid> [reason]\n")
+		return
+	}
+	uid, err := parseID(params[0])
+	if err != nil {
+		c.Show(err.Error() + "\n")
+		return
+	}
+
+	reason := "You are kicked by server."
+	if len(params) > 1 {
+		reason = strings.Join(params[1:], " ")
+	}
+	if err := r.KickUser(b.UserID(uid), reason); err != nil {
+		c.Show(err.Error() + "\n")
+		return
+	}
+	logger.Infof("User %d is kicked by console: %s \n", uid, reason)
+}
+
+func muteFunc(c *cmdface.Console, params []string) {
+	if len(params) != 2 {
+		c.Show("Usage: mute <uid> <duration>\n")
+		return
+	}
+	uid, err := parseID(params[0])
+	if err != nil {
+		c.Show(err.Error() + "\n")
+		return
+	}
+	d, err := time.ParseDuration(params[1])
+	if err != nil || d <= 0 {
+		c.Show(fmt.Sprintf("Invalid duration '%s'.\n", params[1]))
+		return
+	}
+
+	r.MuteUser(b.UserID(uid), d)
+	logger.Infof("User %d is muted by console for %v. \n", uid, d)
+}
+
+func unmuteFunc(c *cmdface.Console, params []string) {
+	if len(params) != 1 {
+		c.Show("Usage: unmute <uid>\n")
+		return
+	}
+	uid, err := parseID(params[0])
+	if err != nil {
+		c.Show(err.Error() + "\n")
+		return
+	}
+
+	r.UnmuteUser(b.UserID(uid))
+	logger.Infof("User %d is unmuted by console. \n", uid)
+}
+
+func sayFunc(c *cmdface.Console, params []string) {
+	if len(params) < 2 {
+		c.Show("Usage: say <rid|all> <message>\n")
+		return
+	}
+	msg := strings.Join(params[1:], " ")
+	if len(msg) > b.ChatMessageMaxLength {
+		c.Show(fmt.Sprintf("Message is longer than %d bytes.\n", b.ChatMessageMaxLength))
+		return
+	}
+
+	if params[0] == "all" {
+		r.Announce(msg)
+		return
+	}
+
+	rid, err := parseID(params[0])
+	if err != nil {
+		c.Show(err.Error() + "\n")
+		return
+	}
+	room, ok := r.GetRoom(b.RoomID(rid))
+	if !ok {
+		c.Show(fmt.Sprintf("Room %d is not exist.\n", rid))
+		return
+	}
+	room.Say(msg)
+}
+
+func closeRoomFunc(c *cmdface.Console, params []string) {
+	if len(params) != 1 {
+		c.Show("Usage: close-room <rid>\n")
+		return
+	}
+	rid, err := parseID(params[0])
+	if err != nil {
+		c.Show(err.Error() + "\n")
+		return
+	}
+
+	if err := r.CloseRoom(b.RoomID(rid)); err != nil {
+		c.Show(err.Error() + "\n")
+		return
+	}
+	logger.Infof("Room %d is closed by console. \n", rid)
+}
+
+func logLevelFunc(c *cmdface.Console, params []string) {
+	if len(params) == 2 {
+		level, err := log.ParseLevel(params[1])
+		if err != nil {
+			c.Show(err.Error() + "\n")
+			return
+		}
+		name := params[0]
+		if name == "root" {
+			name = ""
+		}
+		if err := b.Log.SetLevel(name, level); err != nil {
+			c.Show(err.Error() + "\n")
+			return
+		}
+	} else if len(params) != 0 {
+		c.Show("Usage: loglevel [<name> <level>]\n")
+		return
+	}
+
+	levels := b.Log.Levels()
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		shown := name
+		if name == "" {
+			shown = "root"
+		}
+		c.Show(fmt.Sprintf("%s\t%s\n", shown, log.LevelName(levels[name])))
+	}
+}
+
+func verboseFunc(c *cmdface.Console, params []string) {
+	if len(params) != 2 {
+		c.Show("Usage: verbose <uid> <seconds>\n")
+		return
+	}
+	uid, err := parseID(params[0])
+	if err != nil {
+		c.Show(err.Error() + "\n")
+		return
+	}
+	seconds, err := strconv.ParseUint(params[1], 10, 32)
+	if err != nil {
+		c.Show(fmt.Sprintf("Invalid seconds '%s'.\n", params[1]))
+		return
+	}
+
+	b.Log.SetVerbose("uid", b.UserID(uid), time.Duration(seconds)*time.Second)
+}
@@ -0,0 +1,101 @@
+package console
+
+import (
+	b "barrage-server/base"
+	"barrage-server/libs/cmdface"
+	"barrage-server/libs/log"
+	r "barrage-server/room"
+	"bytes"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runCommands run command lines on a new console and return its output.
+func runCommands(lines ...string) string {
+	var out bytes.Buffer
+	c := cmdface.NewConsole(strings.NewReader(strings.Join(lines, "\n")+"\n"), &out)
+	setupCommands(c)
+	c.Run("")
+	return out.String()
+}
+
+func TestConsoleCommands(t *testing.T) {
+	r.OpenGameHallAndRooms([]b.RoomID{1, 2})
+
+	s := runCommands("rooms")
+	if !strings.Contains(s, "1\tRoom 1\t0/") || !strings.Contains(s, "2\tRoom 2\t0/") {
+		t.Errorf("Rooms are wrong, get %s.", s)
+	}
+
+	s = runCommands("close-room 2", "rooms")
+	if strings.Contains(s, "Room 2") {
+		t.Errorf("Room 2 should be closed, get %s.", s)
+	}
+	if _, ok := r.GetRoom(2); ok {
+		t.Error("Room 2 should be removed from hall.")
+	}
+
+	s = runCommands("close-room 2", "kick 404", "kick abc", "say 404 hello", "mute 7 forever", "unmute abc")
+	for _, hope := range []string{"Room is not Found.", "User is not Found.", "Invalid id 'abc'.", "Room 404 is not exist.",
+		"Invalid duration 'forever'."} {
+		if !strings.Contains(s, hope) {
+			t.Errorf("Output should contain %s, get %s.", hope, s)
+		}
+	}
+}
+
+func TestConsoleLogLevel(t *testing.T) {
+	defer b.Log.SetLevel("console", log.InfoLevel)
+
+	s := runCommands("loglevel console warn")
+	if !strings.Contains(s, "console\twarn\n") || !strings.Contains(s, "root\t") {
+		t.Errorf("Levels of loggers are wrong, get %s.", s)
+	}
+
+	s = runCommands("loglevel console loud", "loglevel nobody info")
+	if !strings.Contains(s, "Invalid log level.") || !strings.Contains(s, "Logger nobody is not exist.") {
+		t.Errorf("Errors of loglevel are wrong, get %s.", s)
+	}
+}
+
+func TestConsoleSocketFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// regular file is never removed.
+	path := filepath.Join(dir, "console.sock")
+	ioutil.WriteFile(path, []byte("data"), 0600)
+	if err := removeStaleSocket(path); err == nil {
+		t.Error("Regular file should not be removed.")
+	}
+	os.Remove(path)
+
+	l, err := listenUnix(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		t.Errorf("Socket should only be accessed by owner, get %v.", perm)
+	}
+	// keep the socket file as if the process was killed.
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+
+	if err := removeStaleSocket(path); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("Stale socket should be removed, get %v.", err)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package console
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenUnix listen on the unix domain socket path, the socket is created with mode
+// 0600 by umask, so nobody else can connect it before chmod. umask is set for the whole
+// process for a moment, files created meanwhile get no more permissions than 0600.
+func listenUnix(path string) (net.Listener, error) {
+	old := syscall.Umask(0177)
+	defer syscall.Umask(old)
+
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package console
+
+import "net"
+
+// listenUnix listen on the unix domain socket path, windows has no umask, so the
+// permission of socket is only set by chmod.
+func listenUnix(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
@@ -5,6 +5,7 @@ package bufbo
 import (
 	"bytes"
 	b "encoding/binary"
+	"errors"
 	"io"
 	"math"
 )
@@ -14,6 +15,9 @@ const (
 	NotEnoughError = "runtime error: index out of range or some error throw out."
 )
 
+// ErrNotEnough is recorded by checked BytesReader while bytes are not enough.
+var ErrNotEnough = errors.New("Bytes are not enough.")
+
 // OrderReader specifies how to read byte sequences into 16-, 32-, or 64-bit unsigned integers from
 // buffer or other type which could remember reading state.
 type OrderReader interface {
@@ -24,6 +28,12 @@ type OrderReader interface {
 	Float32() float32
 	Float64() float64
 	Str(length int) string
+
+	Bool() bool
+	Uvarint() uint64
+	Varint() int64
+	PrefixedStr(p Prefix) string
+	PrefixedBytes(p Prefix) []byte
 }
 
 // OrderWriter specifies how to write 16-, 32-, or 64-bit unsigned integers as byte sequences from
@@ -36,6 +46,12 @@ type OrderWriter interface {
 	PutFloat32(float32)
 	PutFloat64(float64)
 	PutStr(string)
+
+	PutBool(bool)
+	PutUvarint(uint64)
+	PutVarint(int64)
+	PutPrefixedStr(s string, p Prefix)
+	PutPrefixedBytes(bs []byte, p Prefix)
 }
 
 // BytesWriter implement OrderWriter using []byte and binary.ByteOrder.
@@ -203,10 +219,17 @@ func (bfw *bufWriter) PutStr(s string) {
 }
 
 // BytesReader implement OrderReader using io.Buffer and encoding.binary.BigEndian.
+//
+// BytesReader panics while bytes are not enough, but checked BytesReader returns zero
+// value instead and records ErrNotEnough, which is returned by Err. after the first
+// error, all reading of checked BytesReader returns zero value.
 type BytesReader struct {
 	r      []byte
 	length int
 	endian b.ByteOrder
+
+	checked bool
+	err     error
 }
 
 // NewBEBytesReader creates BytesReader by binary.BigEndian.
@@ -225,8 +248,57 @@ func NewLEBytesReader(r []byte) *BytesReader {
 	}
 }
 
+// NewBECheckedReader creates checked BytesReader by binary.BigEndian.
+func NewBECheckedReader(r []byte) *BytesReader {
+	return &BytesReader{
+		r:       r,
+		endian:  b.BigEndian,
+		checked: true,
+	}
+}
+
+// NewLECheckedReader creates checked BytesReader by binary.LittleEndian.
+func NewLECheckedReader(r []byte) *BytesReader {
+	return &BytesReader{
+		r:       r,
+		endian:  b.LittleEndian,
+		checked: true,
+	}
+}
+
+// Err return the first error of checked BytesReader, it is nil if all reading succeed.
+func (bsr *BytesReader) Err() error {
+	return bsr.err
+}
+
+// Remaining return the number of bytes not read.
+func (bsr *BytesReader) Remaining() int {
+	if bsr.length > len(bsr.r) {
+		return 0
+	}
+	return len(bsr.r) - bsr.length
+}
+
+// check return whether n bytes can be read, it is always true for unchecked BytesReader.
+func (bsr *BytesReader) check(n int) bool {
+	if !bsr.checked {
+		return true
+	}
+	if bsr.err != nil {
+		return false
+	}
+	if n < 0 || n > bsr.Remaining() {
+		bsr.err = ErrNotEnough
+		return false
+	}
+	return true
+}
+
 // Uint8 read one byte from BytesReader.
 func (bsr *BytesReader) Uint8() (result uint8) {
+	if !bsr.check(1) {
+		return
+	}
 	result = bsr.r[bsr.length]
 	bsr.length++
 	return
@@ -234,6 +306,9 @@ func (bsr *BytesReader) Uint8() (result uint8) {
 
 // Uint16 read two bytes then convert them to uint16.
 func (bsr *BytesReader) Uint16() (result uint16) {
+	if !bsr.check(2) {
+		return
+	}
 	result = bsr.endian.Uint16(bsr.r[bsr.length:])
 	bsr.length += 2
 	return
@@ -241,6 +316,9 @@ func (bsr *BytesReader) Uint16() (result uint16) {
 
 // Uint32 read four bytes then convert them to uint32
 func (bsr *BytesReader) Uint32() (result uint32) {
+	if !bsr.check(4) {
+		return
+	}
 	result = bsr.endian.Uint32(bsr.r[bsr.length:])
 	bsr.length += 4
 	return
@@ -248,6 +326,9 @@ func (bsr *BytesReader) Uint32() (result uint32) {
 
 // Uint64 read eight bytes then convert them to uint64
 func (bsr *BytesReader) Uint64() (result uint64) {
+	if !bsr.check(8) {
+		return
+	}
 	result = bsr.endian.Uint64(bsr.r[bsr.length:])
 	bsr.length += 8
 	return
@@ -255,6 +336,9 @@ func (bsr *BytesReader) Uint64() (result uint64) {
 
 // Float32 read eight bytes then convert them to float32
 func (bsr *BytesReader) Float32() (result float32) {
+	if !bsr.check(4) {
+		return
+	}
 	result = math.Float32frombits(bsr.endian.Uint32(bsr.r[bsr.length:]))
 	bsr.length += 4
 	return
@@ -262,6 +346,9 @@ func (bsr *BytesReader) Float32() (result float32) {
 
 // Float64 read eight bytes then convert them to float64
 func (bsr *BytesReader) Float64() (result float64) {
+	if !bsr.check(8) {
+		return
+	}
 	result = math.Float64frombits(bsr.endian.Uint64(bsr.r[bsr.length:]))
 	bsr.length += 8
 	return
@@ -269,6 +356,9 @@ func (bsr *BytesReader) Float64() (result float64) {
 
 // Str read length bytes then convert them to string
 func (bsr *BytesReader) Str(length int) string {
+	if !bsr.check(length) {
+		return ""
+	}
 	bs := bsr.r[bsr.length : bsr.length+length]
 	bsr.length += length
 	return string(bs)
@@ -112,6 +112,51 @@ func TestBytesReader(t *testing.T) {
 	br.Uint8()
 }
 
+func TestCheckedBytesReader(t *testing.T) {
+	r := generateBETestBytes()
+	br := NewBECheckedReader(r)
+
+	if result := br.Uint8(); result != 99 {
+		t.Errorf("1st number should be 99(uint8), but get %v.", result)
+	}
+	if result := br.Uint64(); result != 99 {
+		t.Errorf("2nd number should be 99(uint64), but get %v.", result)
+	}
+	if remaining := br.Remaining(); remaining != replyLen-9 {
+		t.Errorf("Remaining is wrong, hope %d, get %d.", replyLen-9, remaining)
+	}
+	if err := br.Err(); err != nil {
+		t.Errorf("Err should be nil, but get %v.", err)
+	}
+
+	// reading more bytes than remaining fails without moving position.
+	if result := br.Str(replyLen); result != "" {
+		t.Errorf("String out of range should be empty, but get %v.", result)
+	}
+	if err := br.Err(); err != ErrNotEnough {
+		t.Errorf("Err is wrong, hope %v, get %v.", ErrNotEnough, err)
+	}
+	if remaining := br.Remaining(); remaining != replyLen-9 {
+		t.Errorf("Remaining is wrong, hope %d, get %d.", replyLen-9, remaining)
+	}
+
+	// error is sticky.
+	if result := br.Uint32(); result != 0 {
+		t.Errorf("Number after error should be 0, but get %v.", result)
+	}
+	if err := br.Err(); err != ErrNotEnough {
+		t.Errorf("Err is wrong, hope %v, get %v.", ErrNotEnough, err)
+	}
+
+	br = NewLECheckedReader([]byte{1, 0})
+	if result := br.Uint16(); result != 1 {
+		t.Errorf("Number should be 1(uint16), but get %v.", result)
+	}
+	if result := br.Float64(); result != 0 || br.Err() != ErrNotEnough {
+		t.Errorf("Reading empty reader should fail, get %v and %v.", result, br.Err())
+	}
+}
+
 func TestBufWriter(t *testing.T) {
 	var w bytes.Buffer
 	bfw := NewBEBufWriter(&w)
@@ -0,0 +1,259 @@
+package bufbo
+
+import (
+	b "encoding/binary"
+	"errors"
+	"math"
+)
+
+var (
+	// ErrOverflow is recorded by checked BytesReader while a varint overflows 64 bits.
+	ErrOverflow = errors.New("Varint overflows a 64-bit integer.")
+	// ErrTooLong is thrown while length of string or bytes can't be encoded by prefix.
+	ErrTooLong = errors.New("Length is too long for prefix.")
+)
+
+// Prefix is the encoding of the length before string or bytes.
+type Prefix uint8
+
+const (
+	// PrefixUint8 encodes length as Uint8, it is used by nickname and most strings in protocal.
+	PrefixUint8 = Prefix(iota)
+	// PrefixUint16 encodes length as Uint16.
+	PrefixUint16
+	// PrefixUint32 encodes length as Uint32.
+	PrefixUint32
+	// PrefixUvarint encodes length as unsigned varint.
+	PrefixUvarint
+)
+
+// MaxLength return the max length can be encoded by prefix.
+func (p Prefix) MaxLength() uint64 {
+	switch p {
+	case PrefixUint8:
+		return math.MaxUint8
+	case PrefixUint16:
+		return math.MaxUint16
+	case PrefixUint32:
+		return math.MaxUint32
+	}
+	return math.MaxInt64
+}
+
+// Size return the number of bytes of string or bytes whose length is n after prefixed.
+func (p Prefix) Size(n int) int {
+	switch p {
+	case PrefixUint8:
+		return 1 + n
+	case PrefixUint16:
+		return 2 + n
+	case PrefixUint32:
+		return 4 + n
+	}
+	return UvarintSize(uint64(n)) + n
+}
+
+// ZigZag map signed integer to unsigned integer so that numbers near zero have small
+// varint encoding, 0, -1, 1, -2 ... are mapped to 0, 1, 2, 3 ...
+func ZigZag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+// UnZigZag is the reverse of ZigZag.
+func UnZigZag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+// UvarintSize return the number of bytes of v encoded as unsigned varint.
+func UvarintSize(v uint64) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+	return n
+}
+
+// VarintSize return the number of bytes of v encoded as zigzag varint.
+func VarintSize(v int64) int {
+	return UvarintSize(ZigZag(v))
+}
+
+// putPrefix write length n by prefix p into w.
+func putPrefix(w OrderWriter, n int, p Prefix) {
+	if uint64(n) > p.MaxLength() {
+		panic(ErrTooLong)
+	}
+
+	switch p {
+	case PrefixUint8:
+		w.PutUint8(uint8(n))
+	case PrefixUint16:
+		w.PutUint16(uint16(n))
+	case PrefixUint32:
+		w.PutUint32(uint32(n))
+	default:
+		w.PutUvarint(uint64(n))
+	}
+}
+
+// PutBool writes bool as one byte, 1 is true and 0 is false.
+func (bsw *BytesWriter) PutBool(v bool) {
+	if v {
+		bsw.PutUint8(1)
+	} else {
+		bsw.PutUint8(0)
+	}
+}
+
+// PutUvarint writes v as unsigned varint.
+func (bsw *BytesWriter) PutUvarint(v uint64) {
+	for v >= 0x80 {
+		bsw.PutUint8(uint8(v) | 0x80)
+		v >>= 7
+	}
+	bsw.PutUint8(uint8(v))
+}
+
+// PutVarint writes v as zigzag varint.
+func (bsw *BytesWriter) PutVarint(v int64) {
+	bsw.PutUvarint(ZigZag(v))
+}
+
+// PutPrefixedStr writes length of s by prefix p, then s. it panics with ErrTooLong
+// if length of s is larger than p.MaxLength().
+func (bsw *BytesWriter) PutPrefixedStr(s string, p Prefix) {
+	putPrefix(bsw, len(s), p)
+	bsw.PutStr(s)
+}
+
+// PutPrefixedBytes writes length of bs by prefix p, then bs. it panics with ErrTooLong
+// if length of bs is larger than p.MaxLength().
+func (bsw *BytesWriter) PutPrefixedBytes(bs []byte, p Prefix) {
+	putPrefix(bsw, len(bs), p)
+	copy(bsw.w[bsw.length:], bs)
+	bsw.length += len(bs)
+}
+
+// PutBool writes bool as one byte, 1 is true and 0 is false.
+func (bfw *bufWriter) PutBool(v bool) {
+	if v {
+		bfw.PutUint8(1)
+	} else {
+		bfw.PutUint8(0)
+	}
+}
+
+// PutUvarint writes v as unsigned varint.
+func (bfw *bufWriter) PutUvarint(v uint64) {
+	bs := make([]byte, b.MaxVarintLen64)
+	n := b.PutUvarint(bs, v)
+
+	if written, _ := bfw.writer.Write(bs[:n]); written < n {
+		panic(NotEnoughError)
+	}
+}
+
+// PutVarint writes v as zigzag varint.
+func (bfw *bufWriter) PutVarint(v int64) {
+	bfw.PutUvarint(ZigZag(v))
+}
+
+// PutPrefixedStr writes length of s by prefix p, then s. it panics with ErrTooLong
+// if length of s is larger than p.MaxLength().
+func (bfw *bufWriter) PutPrefixedStr(s string, p Prefix) {
+	putPrefix(bfw, len(s), p)
+	bfw.PutStr(s)
+}
+
+// PutPrefixedBytes writes length of bs by prefix p, then bs. it panics with ErrTooLong
+// if length of bs is larger than p.MaxLength().
+func (bfw *bufWriter) PutPrefixedBytes(bs []byte, p Prefix) {
+	putPrefix(bfw, len(bs), p)
+	if n, _ := bfw.writer.Write(bs); n < len(bs) {
+		panic(NotEnoughError)
+	}
+}
+
+// fail records err for checked BytesReader, or panics with err.
+func (bsr *BytesReader) fail(err error) {
+	if !bsr.checked {
+		panic(err)
+	}
+	if bsr.err == nil {
+		bsr.err = err
+	}
+}
+
+// Bool read one byte, it is true unless the byte is 0.
+func (bsr *BytesReader) Bool() bool {
+	return bsr.Uint8() != 0
+}
+
+// Uvarint read unsigned varint.
+func (bsr *BytesReader) Uvarint() uint64 {
+	var v uint64
+	for i, shift := 0, uint(0); i < b.MaxVarintLen64; i, shift = i+1, shift+7 {
+		c := bsr.Uint8()
+		if bsr.err != nil {
+			return 0
+		}
+		if c < 0x80 {
+			if i == b.MaxVarintLen64-1 && c > 1 {
+				break
+			}
+			return v | uint64(c)<<shift
+		}
+		v |= uint64(c&0x7f) << shift
+	}
+
+	bsr.fail(ErrOverflow)
+	return 0
+}
+
+// Varint read zigzag varint.
+func (bsr *BytesReader) Varint() int64 {
+	return UnZigZag(bsr.Uvarint())
+}
+
+// prefixedLength read length by prefix p, it fails if bytes after prefix are not enough.
+func (bsr *BytesReader) prefixedLength(p Prefix) int {
+	var n uint64
+	switch p {
+	case PrefixUint8:
+		n = uint64(bsr.Uint8())
+	case PrefixUint16:
+		n = uint64(bsr.Uint16())
+	case PrefixUint32:
+		n = uint64(bsr.Uint32())
+	default:
+		n = bsr.Uvarint()
+	}
+
+	if bsr.err != nil {
+		return 0
+	}
+	if n > uint64(bsr.Remaining()) {
+		bsr.fail(ErrNotEnough)
+		return 0
+	}
+	return int(n)
+}
+
+// PrefixedStr read string whose length is prefixed by p.
+func (bsr *BytesReader) PrefixedStr(p Prefix) string {
+	return bsr.Str(bsr.prefixedLength(p))
+}
+
+// PrefixedBytes read bytes whose length is prefixed by p, the result is a copy.
+func (bsr *BytesReader) PrefixedBytes(p Prefix) []byte {
+	n := bsr.prefixedLength(p)
+	if bsr.err != nil {
+		return nil
+	}
+
+	bs := make([]byte, n)
+	copy(bs, bsr.r[bsr.length:])
+	bsr.length += n
+	return bs
+}
@@ -0,0 +1,117 @@
+package bufbo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+var testVarints = []int64{0, 1, -1, 63, -64, 64, 300, -300, math.MaxInt32, math.MaxInt64, math.MinInt64}
+
+func TestZigZagAndVarintSize(t *testing.T) {
+	for i, v := range []int64{0, -1, 1, -2, 2} {
+		if zz := ZigZag(v); zz != uint64(i) {
+			t.Errorf("ZigZag of %d is wrong, hope %d, get %d.", v, i, zz)
+		}
+	}
+
+	buf := make([]byte, binary.MaxVarintLen64)
+	for _, v := range testVarints {
+		if uv := UnZigZag(ZigZag(v)); uv != v {
+			t.Errorf("UnZigZag is wrong, hope %d, get %d.", v, uv)
+		}
+		if size, hope := VarintSize(v), binary.PutVarint(buf, v); size != hope {
+			t.Errorf("VarintSize of %d is wrong, hope %d, get %d.", v, hope, size)
+		}
+	}
+}
+
+// writeTestValues writes the same values by BytesWriter and bufWriter.
+func writeTestValues(w OrderWriter) {
+	w.PutBool(true)
+	w.PutBool(false)
+	for _, v := range testVarints {
+		w.PutVarint(v)
+	}
+	w.PutUvarint(math.MaxUint64)
+	w.PutPrefixedStr("nickname", PrefixUint8)
+	w.PutPrefixedStr(strings.Repeat("a", 300), PrefixUint16)
+	w.PutPrefixedBytes([]byte{1, 2, 3}, PrefixUint32)
+	w.PutPrefixedStr(strings.Repeat("b", 200), PrefixUvarint)
+}
+
+// testValuesSize is the number of bytes written by writeTestValues.
+func testValuesSize() int {
+	size := 2 + UvarintSize(math.MaxUint64) + PrefixUint8.Size(8) + PrefixUint16.Size(300) +
+		PrefixUint32.Size(3) + PrefixUvarint.Size(200)
+	for _, v := range testVarints {
+		size += VarintSize(v)
+	}
+	return size
+}
+
+func TestHelpersWriteAndRead(t *testing.T) {
+	bs := make([]byte, testValuesSize())
+	writeTestValues(NewBEBytesWriter(bs))
+
+	var buffer bytes.Buffer
+	writeTestValues(NewBEBufWriter(&buffer))
+	if !bytes.Equal(bs, buffer.Bytes()) {
+		t.Errorf("Bytes of BytesWriter and bufWriter are different, % x and % x.", bs, buffer.Bytes())
+	}
+
+	br := NewBECheckedReader(bs)
+	if v1, v2 := br.Bool(), br.Bool(); !v1 || v2 {
+		t.Errorf("Bools are wrong, hope true and false, get %v and %v.", v1, v2)
+	}
+	for _, hope := range testVarints {
+		if v := br.Varint(); v != hope {
+			t.Errorf("Varint is wrong, hope %d, get %d.", hope, v)
+		}
+	}
+	if v := br.Uvarint(); v != math.MaxUint64 {
+		t.Errorf("Uvarint is wrong, hope %d, get %d.", uint64(math.MaxUint64), v)
+	}
+	if s := br.PrefixedStr(PrefixUint8); s != "nickname" {
+		t.Errorf("String is wrong, hope nickname, get %s.", s)
+	}
+	if s := br.PrefixedStr(PrefixUint16); s != strings.Repeat("a", 300) {
+		t.Errorf("Length of string is wrong, hope 300, get %d.", len(s))
+	}
+	if v := br.PrefixedBytes(PrefixUint32); !bytes.Equal(v, []byte{1, 2, 3}) {
+		t.Errorf("Bytes are wrong, hope 01 02 03, get % x.", v)
+	}
+	if s := br.PrefixedStr(PrefixUvarint); s != strings.Repeat("b", 200) {
+		t.Errorf("Length of string is wrong, hope 200, get %d.", len(s))
+	}
+	if err, remaining := br.Err(), br.Remaining(); err != nil || remaining != 0 {
+		t.Errorf("Reader should be over without error, get %v and %d bytes.", err, remaining)
+	}
+}
+
+func TestHelpersErrors(t *testing.T) {
+	// length larger than remaining bytes.
+	br := NewBECheckedReader([]byte{5, 'a', 'b'})
+	if s := br.PrefixedStr(PrefixUint8); s != "" || br.Err() != ErrNotEnough {
+		t.Errorf("Short string should fail, get %q and %v.", s, br.Err())
+	}
+
+	br = NewBECheckedReader(bytes.Repeat([]byte{0xff}, 11))
+	if v := br.Uvarint(); v != 0 || br.Err() != ErrOverflow {
+		t.Errorf("Overflowed varint should fail, get %d and %v.", v, br.Err())
+	}
+
+	br = NewBECheckedReader([]byte{0x80})
+	if v := br.Uvarint(); v != 0 || br.Err() != ErrNotEnough {
+		t.Errorf("Cut varint should fail, get %d and %v.", v, br.Err())
+	}
+
+	defer func() {
+		if err := recover(); err != ErrTooLong {
+			t.Errorf("PutPrefixedStr should panic with %v, but get %v.", ErrTooLong, err)
+		}
+	}()
+	NewBEBytesWriter(make([]byte, 300)).PutPrefixedStr(strings.Repeat("a", 256), PrefixUint8)
+}
@@ -0,0 +1,369 @@
+package bufbo
+
+import (
+	"bufio"
+	"bytes"
+	b "encoding/binary"
+	"io"
+	"math"
+)
+
+// StreamWriter implement OrderWriter using buffered io.Writer and binary.ByteOrder, so
+// data can be written to a connection or file without computing its size first.
+//
+// StreamWriter doesn't panic, it records the first error, which is returned by Err and
+// Flush. after the first error, all writing is ignored. it is not concurrently safe.
+type StreamWriter struct {
+	w       *bufio.Writer
+	endian  b.ByteOrder
+	scratch [b.MaxVarintLen64]byte
+
+	written int64
+	err     error
+}
+
+// NewBEStreamWriter creates StreamWriter by binary.BigEndian.
+func NewBEStreamWriter(w io.Writer) *StreamWriter {
+	return &StreamWriter{
+		w:      bufio.NewWriter(w),
+		endian: b.BigEndian,
+	}
+}
+
+// NewLEStreamWriter creates StreamWriter by binary.LittleEndian.
+func NewLEStreamWriter(w io.Writer) *StreamWriter {
+	return &StreamWriter{
+		w:      bufio.NewWriter(w),
+		endian: b.LittleEndian,
+	}
+}
+
+// Err return the first error of writing.
+func (sw *StreamWriter) Err() error {
+	return sw.err
+}
+
+// Written return the number of bytes written into StreamWriter, including bytes not flushed.
+func (sw *StreamWriter) Written() int64 {
+	return sw.written
+}
+
+// Flush writes buffered bytes into the underlying io.Writer, it return the first error
+// of writing or flushing.
+func (sw *StreamWriter) Flush() error {
+	if sw.err != nil {
+		return sw.err
+	}
+	sw.err = sw.w.Flush()
+	return sw.err
+}
+
+// write writes bs unless there is an error.
+func (sw *StreamWriter) write(bs []byte) {
+	if sw.err != nil {
+		return
+	}
+	n, err := sw.w.Write(bs)
+	sw.written += int64(n)
+	sw.err = err
+}
+
+// PutUint8 writes one byte into StreamWriter.
+func (sw *StreamWriter) PutUint8(v uint8) {
+	if sw.err != nil {
+		return
+	}
+	if sw.err = sw.w.WriteByte(v); sw.err == nil {
+		sw.written++
+	}
+}
+
+// PutUint16 writes two bytes into StreamWriter.
+func (sw *StreamWriter) PutUint16(v uint16) {
+	sw.endian.PutUint16(sw.scratch[:], v)
+	sw.write(sw.scratch[:2])
+}
+
+// PutUint32 writes 4 bytes into StreamWriter.
+func (sw *StreamWriter) PutUint32(v uint32) {
+	sw.endian.PutUint32(sw.scratch[:], v)
+	sw.write(sw.scratch[:4])
+}
+
+// PutUint64 writes 8 bytes into StreamWriter.
+func (sw *StreamWriter) PutUint64(v uint64) {
+	sw.endian.PutUint64(sw.scratch[:], v)
+	sw.write(sw.scratch[:8])
+}
+
+// PutFloat32 writes 4 bytes into StreamWriter.
+func (sw *StreamWriter) PutFloat32(v float32) {
+	sw.PutUint32(math.Float32bits(v))
+}
+
+// PutFloat64 writes 8 bytes into StreamWriter.
+func (sw *StreamWriter) PutFloat64(v float64) {
+	sw.PutUint64(math.Float64bits(v))
+}
+
+// PutStr writes string into StreamWriter.
+func (sw *StreamWriter) PutStr(s string) {
+	if sw.err != nil {
+		return
+	}
+	n, err := sw.w.WriteString(s)
+	sw.written += int64(n)
+	sw.err = err
+}
+
+// PutBytes writes bs into StreamWriter.
+func (sw *StreamWriter) PutBytes(bs []byte) {
+	sw.write(bs)
+}
+
+// PutBool writes bool as one byte, 1 is true and 0 is false.
+func (sw *StreamWriter) PutBool(v bool) {
+	if v {
+		sw.PutUint8(1)
+	} else {
+		sw.PutUint8(0)
+	}
+}
+
+// PutUvarint writes v as unsigned varint.
+func (sw *StreamWriter) PutUvarint(v uint64) {
+	n := b.PutUvarint(sw.scratch[:], v)
+	sw.write(sw.scratch[:n])
+}
+
+// PutVarint writes v as zigzag varint.
+func (sw *StreamWriter) PutVarint(v int64) {
+	sw.PutUvarint(ZigZag(v))
+}
+
+// PutPrefixedStr writes length of s by prefix p, then s. ErrTooLong is recorded
+// if length of s is larger than p.MaxLength().
+func (sw *StreamWriter) PutPrefixedStr(s string, p Prefix) {
+	if sw.putPrefix(len(s), p) {
+		sw.PutStr(s)
+	}
+}
+
+// PutPrefixedBytes writes length of bs by prefix p, then bs. ErrTooLong is recorded
+// if length of bs is larger than p.MaxLength().
+func (sw *StreamWriter) PutPrefixedBytes(bs []byte, p Prefix) {
+	if sw.putPrefix(len(bs), p) {
+		sw.write(bs)
+	}
+}
+
+// putPrefix writes length n by prefix p, it return false if n is too long.
+func (sw *StreamWriter) putPrefix(n int, p Prefix) bool {
+	if uint64(n) > p.MaxLength() {
+		if sw.err == nil {
+			sw.err = ErrTooLong
+		}
+		return false
+	}
+	putPrefix(sw, n, p)
+	return true
+}
+
+// StreamReader implement OrderReader using buffered io.Reader and binary.ByteOrder.
+//
+// StreamReader doesn't panic, it returns zero value and records the first error, which
+// is returned by Err. io.EOF is recorded if the stream is over before a reading, and
+// io.ErrUnexpectedEOF is recorded if it is over in the middle of a reading. after the
+// first error, all reading returns zero value. it is not concurrently safe.
+type StreamReader struct {
+	r       *bufio.Reader
+	endian  b.ByteOrder
+	scratch [8]byte
+
+	offset int64
+	err    error
+}
+
+// NewBEStreamReader creates StreamReader by binary.BigEndian.
+func NewBEStreamReader(r io.Reader) *StreamReader {
+	return &StreamReader{
+		r:      bufio.NewReader(r),
+		endian: b.BigEndian,
+	}
+}
+
+// NewLEStreamReader creates StreamReader by binary.LittleEndian.
+func NewLEStreamReader(r io.Reader) *StreamReader {
+	return &StreamReader{
+		r:      bufio.NewReader(r),
+		endian: b.LittleEndian,
+	}
+}
+
+// Err return the first error of reading.
+func (sr *StreamReader) Err() error {
+	return sr.err
+}
+
+// Offset return the number of bytes read from StreamReader.
+func (sr *StreamReader) Offset() int64 {
+	return sr.offset
+}
+
+// readFull fill bs, it return false if there is an error.
+func (sr *StreamReader) readFull(bs []byte) bool {
+	if sr.err != nil {
+		return false
+	}
+	n, err := io.ReadFull(sr.r, bs)
+	sr.offset += int64(n)
+	sr.err = err
+	return err == nil
+}
+
+// Uint8 read one byte from StreamReader.
+func (sr *StreamReader) Uint8() uint8 {
+	if !sr.readFull(sr.scratch[:1]) {
+		return 0
+	}
+	return sr.scratch[0]
+}
+
+// Uint16 read two bytes then convert them to uint16.
+func (sr *StreamReader) Uint16() uint16 {
+	if !sr.readFull(sr.scratch[:2]) {
+		return 0
+	}
+	return sr.endian.Uint16(sr.scratch[:])
+}
+
+// Uint32 read four bytes then convert them to uint32.
+func (sr *StreamReader) Uint32() uint32 {
+	if !sr.readFull(sr.scratch[:4]) {
+		return 0
+	}
+	return sr.endian.Uint32(sr.scratch[:])
+}
+
+// Uint64 read eight bytes then convert them to uint64.
+func (sr *StreamReader) Uint64() uint64 {
+	if !sr.readFull(sr.scratch[:8]) {
+		return 0
+	}
+	return sr.endian.Uint64(sr.scratch[:])
+}
+
+// Float32 read four bytes then convert them to float32.
+func (sr *StreamReader) Float32() float32 {
+	return math.Float32frombits(sr.Uint32())
+}
+
+// Float64 read eight bytes then convert them to float64.
+func (sr *StreamReader) Float64() float64 {
+	return math.Float64frombits(sr.Uint64())
+}
+
+// Str read length bytes then convert them to string.
+func (sr *StreamReader) Str(length int) string {
+	return string(sr.Bytes(length))
+}
+
+// Bytes read length bytes. the buffer grows while bytes arrive, so a broken length
+// doesn't allocate memory more than the stream holds.
+func (sr *StreamReader) Bytes(length int) []byte {
+	if sr.err != nil {
+		return nil
+	}
+	if length < 0 {
+		sr.err = ErrNotEnough
+		return nil
+	}
+
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, sr.r, int64(length))
+	sr.offset += n
+	if err != nil {
+		if err == io.EOF && n > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		sr.err = err
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// Bool read one byte, it is true unless the byte is 0.
+func (sr *StreamReader) Bool() bool {
+	return sr.Uint8() != 0
+}
+
+// Uvarint read unsigned varint.
+func (sr *StreamReader) Uvarint() uint64 {
+	var v uint64
+	for i, shift := 0, uint(0); i < b.MaxVarintLen64; i, shift = i+1, shift+7 {
+		c := sr.Uint8()
+		if sr.err != nil {
+			if sr.err == io.EOF && i > 0 {
+				sr.err = io.ErrUnexpectedEOF
+			}
+			return 0
+		}
+		if c < 0x80 {
+			if i == b.MaxVarintLen64-1 && c > 1 {
+				break
+			}
+			return v | uint64(c)<<shift
+		}
+		v |= uint64(c&0x7f) << shift
+	}
+
+	sr.err = ErrOverflow
+	return 0
+}
+
+// Varint read zigzag varint.
+func (sr *StreamReader) Varint() int64 {
+	return UnZigZag(sr.Uvarint())
+}
+
+// prefixedLength read length by prefix p.
+func (sr *StreamReader) prefixedLength(p Prefix) int {
+	var n uint64
+	switch p {
+	case PrefixUint8:
+		n = uint64(sr.Uint8())
+	case PrefixUint16:
+		n = uint64(sr.Uint16())
+	case PrefixUint32:
+		n = uint64(sr.Uint32())
+	default:
+		n = sr.Uvarint()
+	}
+
+	if sr.err == nil && (int(n) < 0 || uint64(int(n)) != n) {
+		sr.err = ErrTooLong
+	}
+	if sr.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// PrefixedStr read string whose length is prefixed by p.
+func (sr *StreamReader) PrefixedStr(p Prefix) string {
+	return string(sr.PrefixedBytes(p))
+}
+
+// PrefixedBytes read bytes whose length is prefixed by p.
+func (sr *StreamReader) PrefixedBytes(p Prefix) []byte {
+	n := sr.prefixedLength(p)
+	if sr.err != nil {
+		return nil
+	}
+
+	bs := sr.Bytes(n)
+	if sr.err == io.EOF {
+		sr.err = io.ErrUnexpectedEOF
+	}
+	return bs
+}
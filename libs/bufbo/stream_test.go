@@ -0,0 +1,152 @@
+package bufbo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestStreamWriteAndRead(t *testing.T) {
+	var buffer bytes.Buffer
+	sw := NewBEStreamWriter(&buffer)
+	sw.PutUint8(99)
+	sw.PutUint64(99)
+	sw.PutUint32(99)
+	sw.PutUint16(99)
+	sw.PutUint8(99)
+	sw.PutUint64(99)
+	sw.PutFloat64(99)
+	sw.PutFloat32(99)
+	sw.PutStr("9999 9999")
+	writeTestValues(sw)
+
+	if buffer.Len() != 0 {
+		t.Errorf("Bytes should be buffered before flushed, get %d bytes.", buffer.Len())
+	}
+	if err := sw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	hope := append(generateBETestBytes(), make([]byte, testValuesSize())...)
+	writeTestValues(NewBEBytesWriter(hope[replyLen:]))
+	if !bytes.Equal(buffer.Bytes(), hope) {
+		t.Errorf("Bytes of StreamWriter are wrong, hope % x, get % x.", hope, buffer.Bytes())
+	}
+	if sw.Written() != int64(len(hope)) {
+		t.Errorf("Written is wrong, hope %d, get %d.", len(hope), sw.Written())
+	}
+
+	sr := NewBEStreamReader(&buffer)
+	if sr.Uint8() != 99 || sr.Uint64() != 99 || sr.Uint32() != 99 || sr.Uint16() != 99 ||
+		sr.Uint8() != 99 || sr.Uint64() != 99 || sr.Float64() != 99 || sr.Float32() != 99 {
+		t.Error("Numbers of StreamReader are wrong, hope 99.")
+	}
+	if s := sr.Str(9); s != "9999 9999" {
+		t.Errorf("String is wrong, hope 9999 9999, get %s.", s)
+	}
+	if v1, v2 := sr.Bool(), sr.Bool(); !v1 || v2 {
+		t.Errorf("Bools are wrong, hope true and false, get %v and %v.", v1, v2)
+	}
+	for _, hope := range testVarints {
+		if v := sr.Varint(); v != hope {
+			t.Errorf("Varint is wrong, hope %d, get %d.", hope, v)
+		}
+	}
+	if v := sr.Uvarint(); v != math.MaxUint64 {
+		t.Errorf("Uvarint is wrong, hope %d, get %d.", uint64(math.MaxUint64), v)
+	}
+	if s := sr.PrefixedStr(PrefixUint8); s != "nickname" {
+		t.Errorf("String is wrong, hope nickname, get %s.", s)
+	}
+	if s := sr.PrefixedStr(PrefixUint16); s != strings.Repeat("a", 300) {
+		t.Errorf("Length of string is wrong, hope 300, get %d.", len(s))
+	}
+	if v := sr.PrefixedBytes(PrefixUint32); !bytes.Equal(v, []byte{1, 2, 3}) {
+		t.Errorf("Bytes are wrong, hope 01 02 03, get % x.", v)
+	}
+	if s := sr.PrefixedStr(PrefixUvarint); s != strings.Repeat("b", 200) {
+		t.Errorf("Length of string is wrong, hope 200, get %d.", len(s))
+	}
+	if err := sr.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if sr.Offset() != int64(len(hope)) {
+		t.Errorf("Offset is wrong, hope %d, get %d.", len(hope), sr.Offset())
+	}
+
+	// stream is over.
+	if v := sr.Uint32(); v != 0 || sr.Err() != io.EOF {
+		t.Errorf("Reading after the end should fail with EOF, get %d and %v.", v, sr.Err())
+	}
+}
+
+func TestStreamReaderErrors(t *testing.T) {
+	sr := NewBEStreamReader(bytes.NewReader([]byte{0, 1}))
+	if v := sr.Uint32(); v != 0 || sr.Err() != io.ErrUnexpectedEOF {
+		t.Errorf("Cut Uint32 should fail, get %d and %v.", v, sr.Err())
+	}
+	if v := sr.Uint8(); v != 0 || sr.Err() != io.ErrUnexpectedEOF {
+		t.Errorf("Reading after error should fail, get %d and %v.", v, sr.Err())
+	}
+
+	// length is much larger than the stream.
+	sr = NewBEStreamReader(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 'a'}))
+	if s := sr.PrefixedStr(PrefixUint32); s != "" || sr.Err() != io.ErrUnexpectedEOF {
+		t.Errorf("Short string should fail, get %q and %v.", s, sr.Err())
+	}
+
+	sr = NewBEStreamReader(bytes.NewReader([]byte{3}))
+	if v := sr.PrefixedBytes(PrefixUint8); v != nil || sr.Err() != io.ErrUnexpectedEOF {
+		t.Errorf("Missing bytes should fail, get % x and %v.", v, sr.Err())
+	}
+
+	sr = NewBEStreamReader(bytes.NewReader(bytes.Repeat([]byte{0xff}, 11)))
+	if v := sr.Uvarint(); v != 0 || sr.Err() != ErrOverflow {
+		t.Errorf("Overflowed varint should fail, get %d and %v.", v, sr.Err())
+	}
+
+	sr = NewBEStreamReader(bytes.NewReader([]byte{0x80}))
+	if v := sr.Uvarint(); v != 0 || sr.Err() != io.ErrUnexpectedEOF {
+		t.Errorf("Cut varint should fail, get %d and %v.", v, sr.Err())
+	}
+}
+
+// errWriter fails after limit bytes are written.
+type errWriter struct {
+	limit int
+}
+
+var errTestWrite = errors.New("Test write error.")
+
+func (ew *errWriter) Write(bs []byte) (int, error) {
+	if len(bs) > ew.limit {
+		n := ew.limit
+		ew.limit = 0
+		return n, errTestWrite
+	}
+	ew.limit -= len(bs)
+	return len(bs), nil
+}
+
+func TestStreamWriterErrors(t *testing.T) {
+	sw := NewBEStreamWriter(&errWriter{limit: 10})
+	sw.PutStr(strings.Repeat("a", 8192))
+	if err := sw.Err(); err != errTestWrite {
+		t.Errorf("Error of writing is wrong, hope %v, get %v.", errTestWrite, err)
+	}
+	sw.PutUint32(1)
+	if err := sw.Flush(); err != errTestWrite {
+		t.Errorf("Error of flushing is wrong, hope %v, get %v.", errTestWrite, err)
+	}
+
+	var buffer bytes.Buffer
+	sw = NewBEStreamWriter(&buffer)
+	sw.PutPrefixedStr(strings.Repeat("a", 256), PrefixUint8)
+	sw.PutUint8(1)
+	if err := sw.Flush(); err != ErrTooLong || buffer.Len() != 0 {
+		t.Errorf("Too long string should fail, get %v and %d bytes.", err, buffer.Len())
+	}
+}
@@ -0,0 +1,56 @@
+package cmdface
+
+import (
+	"errors"
+	"strings"
+)
+
+var errUnterminatedQuote = errors.New("Unterminated quote.")
+
+// splitArgs split a command line into words by spaces, words can be quoted by
+// double or single quotes to contain spaces, backslash escapes the next character
+// outside single quotes.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, errUnterminatedQuote
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,38 @@
+package cmdface
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	cases := []struct {
+		line string
+		hope []string
+	}{
+		{"", nil},
+		{"  sci  1 ", []string{"sci", "1"}},
+		{`say 1 "hello world"`, []string{"say", "1", "hello world"}},
+		{`say 1 'it''s'`, []string{"say", "1", "its"}},
+		{`say 1 "a \"b\""`, []string{"say", "1", `a "b"`}},
+		{`say 1 hello\ world ""`, []string{"say", "1", "hello world", ""}},
+		{`say 1 'a\b'`, []string{"say", "1", `a\b`}},
+	}
+
+	for _, c := range cases {
+		args, err := splitArgs(c.line)
+		if err != nil {
+			t.Errorf("splitArgs(%q) fails: %v.", c.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(args, c.hope) {
+			t.Errorf("splitArgs(%q) is wrong, hope %q, get %q.", c.line, c.hope, args)
+		}
+	}
+
+	for _, line := range []string{`say "hello`, `say 'hello`, `say hello\`} {
+		if _, err := splitArgs(line); err != errUnterminatedQuote {
+			t.Errorf("splitArgs(%q) should fail, get %v.", line, err)
+		}
+	}
+}
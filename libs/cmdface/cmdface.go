@@ -1,46 +1,26 @@
+// Package cmdface provides a simple command line interface, commands are registered
+// with AddCommand then run by lines from input.
 package cmdface
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"sync"
 )
 
-const (
-	inputBufSize = 1024
-)
-
-var inputBuf []byte
-var strChan chan string
+var errCmdNotFind = errors.New("Command Not Find.")
 
-var cmdLinkOfUser *commandNode
-var cmdLinkOfSys *commandNode
-var cmdlTail *commandNode
+// std is the console on stdin and stdout used by package level functions.
+var std = NewConsole(os.Stdin, os.Stdout)
 
 func init() {
-	inputBuf = make([]byte, inputBufSize)
-
-	strChan = make(chan string)
-	shower(strChan)
-
-	cmdLinkOfSys = createSysCommandLink()
-	cmdlTail = nil
-}
-
-// shower open a goroutine to capture string from strChan, then print them.
-func shower(strChan <-chan string) {
-	go func() {
-		for {
-			str, ok := <-strChan
-			if !ok {
-				break
-			}
-			fmt.Print(str)
-		}
-		fmt.Println("testClient shower ready.")
-	}()
+	std.onExit = func() {
+		os.Exit(0)
+	}
 }
 
 // commandNode is the node of command link list of user(sys).
@@ -51,59 +31,150 @@ type commandNode struct {
 	next    *commandNode
 }
 
-func helpFunc(params []string) {
-	Show("=================All Commands==================\n")
+// Console reads command lines from its input, runs commands and shows results on its output,
+// it is used on stdin by testClient and on sockets by the admin console of server.
+type Console struct {
+	reader *bufio.Reader
+
+	outM sync.Mutex
+	out  io.Writer
+
+	cmdLinkOfUser *commandNode
+	cmdLinkOfSys  *commandNode
+	cmdlTail      *commandNode
+	completers    map[string]Completer
+
+	// editor is nil until EnableLineEditing is called.
+	editor *lineEditor
+	script *scriptState
+
+	closeM sync.RWMutex
+	closed bool
+	// onExit is called by exit command.
+	onExit func()
+}
+
+// NewConsole create a console reading from in and writing to out.
+func NewConsole(in io.Reader, out io.Writer) *Console {
+	c := &Console{
+		reader:     bufio.NewReader(in),
+		out:        out,
+		completers: make(map[string]Completer),
+		script:     newScriptState(),
+	}
+	c.cmdLinkOfSys = c.createSysCommandLink()
+
+	return c
+}
+
+func (c *Console) helpFunc(params []string) {
+	c.Show("=================All Commands==================\n")
 	// show user command information
-	cmdn := cmdLinkOfUser
+	cmdn := c.cmdLinkOfUser
 	for cmdn != nil {
-		Show(fmt.Sprintf("%s\t%s\n", cmdn.command, cmdn.summary))
+		c.Show(fmt.Sprintf("%s\t%s\n", cmdn.command, cmdn.summary))
 		cmdn = cmdn.next
 	}
 
-	Show("\n")
+	c.Show("\n")
 	// show sys command information
-	cmdn = cmdLinkOfSys
+	cmdn = c.cmdLinkOfSys
 	for cmdn != nil {
-		Show(fmt.Sprintf("%s\t%s\n", cmdn.command, cmdn.summary))
+		c.Show(fmt.Sprintf("%s\t%s\n", cmdn.command, cmdn.summary))
 		cmdn = cmdn.next
 	}
 }
 
-func exitFunc(params []string) {
-	os.Exit(0)
+func (c *Console) exitFunc(params []string) {
+	c.Close()
+	if c.onExit != nil {
+		c.onExit()
+	}
 }
 
-func createSysCommandLink() *commandNode {
+func (c *Console) createSysCommandLink() *commandNode {
 
 	cmdn := &commandNode{
 		command: "help",
 		summary: "show all commands.",
-		cmdFunc: helpFunc,
+		cmdFunc: c.helpFunc,
 	}
 	cmdn.next = &commandNode{
+		command: "source",
+		summary: "<file>, run commands in script file.",
+		cmdFunc: c.sourceFunc,
+	}
+	cmdn.next.next = &commandNode{
 		command: "exit",
 		summary: "exit command line.",
-		cmdFunc: exitFunc,
+		cmdFunc: c.exitFunc,
 	}
 
 	return cmdn
 }
 
 // AddCommand create a command and push it to then tail of the command link list of user.
-func AddCommand(command, summary string, cmdFunc func(paras []string)) {
+func (c *Console) AddCommand(command, summary string, cmdFunc func(paras []string)) {
 	cmdn := &commandNode{
 		command: command,
 		summary: summary,
 		cmdFunc: cmdFunc,
 	}
 
-	if cmdlTail == nil {
-		cmdLinkOfUser = cmdn
-		cmdlTail = cmdLinkOfUser
+	if c.cmdlTail == nil {
+		c.cmdLinkOfUser = cmdn
+		c.cmdlTail = c.cmdLinkOfUser
 	} else {
-		cmdlTail.next = cmdn
-		cmdlTail = cmdlTail.next
+		c.cmdlTail.next = cmdn
+		c.cmdlTail = c.cmdlTail.next
+	}
+}
+
+// SetCompleter set the completer of arguments of command.
+func (c *Console) SetCompleter(command string, completer Completer) {
+	c.completers[command] = completer
+}
+
+// EnableLineEditing read lines key by key with cursor moving, history and tab completion,
+// lines are appended to historyFile, empty historyFile keeps history in memory only.
+// the input should be a terminal in raw mode.
+func (c *Console) EnableLineEditing(historyFile string) error {
+	hist, err := newHistory(historyFile)
+	if err != nil {
+		return err
+	}
+
+	c.editor = &lineEditor{
+		in:       c.reader,
+		show:     c.Show,
+		hist:     hist,
+		complete: c.complete,
 	}
+	return nil
+}
+
+// complete return candidates of the last word of line, the first word is completed
+// by command names, others are completed by the completer of command.
+func (c *Console) complete(line string) []string {
+	words := strings.Fields(line)
+	if len(words) == 0 || strings.HasSuffix(line, " ") {
+		words = append(words, "")
+	}
+
+	if len(words) == 1 {
+		var names []string
+		for _, link := range []*commandNode{c.cmdLinkOfUser, c.cmdLinkOfSys} {
+			for cmdn := link; cmdn != nil; cmdn = cmdn.next {
+				names = append(names, cmdn.command)
+			}
+		}
+		return names
+	}
+
+	if completer, ok := c.completers[words[0]]; ok {
+		return completer(words[1:])
+	}
+	return nil
 }
 
 func findCmdNode(link *commandNode, cmd string) (resultCmd *commandNode) {
@@ -118,42 +189,153 @@ func findCmdNode(link *commandNode, cmd string) (resultCmd *commandNode) {
 	return resultCmd
 }
 
-// InputAndRunCommand get command line from user inputing, then run command,
-// If command is not find in comand link list of user and sys, return not find error.
-func InputAndRunCommand(msg string) error {
-	inputStr := strings.TrimSpace(Input(msg))
-	cmdlist := strings.Split(inputStr, " ")
+// RunCommand run a command line, If command is not find in comand link list of
+// user and sys, return not find error. empty line does nothing. arguments containing
+// spaces can be quoted.
+func (c *Console) RunCommand(line string) error {
+	cmdlist, err := splitArgs(line)
+	if err != nil {
+		return err
+	}
+	if len(cmdlist) == 0 {
+		return nil
+	}
 
-	if cmd := findCmdNode(cmdLinkOfSys, cmdlist[0]); cmd != nil {
+	if cmd := findCmdNode(c.cmdLinkOfSys, cmdlist[0]); cmd != nil {
 		cmd.cmdFunc(cmdlist[1:])
 		return nil
 	}
 
-	if cmd := findCmdNode(cmdLinkOfUser, cmdlist[0]); cmd != nil {
+	if cmd := findCmdNode(c.cmdLinkOfUser, cmdlist[0]); cmd != nil {
 		cmd.cmdFunc(cmdlist[1:])
 		return nil
 	}
 
-	return errors.New("Command Not Find.")
+	return errCmdNotFind
 }
 
-// Show send string to strChan to print out string synchronously.
+// InputAndRunCommand get command line from input, then run command,
+// it returns io.EOF when input is over.
+func (c *Console) InputAndRunCommand(msg string) error {
+	line, err := c.Input(msg)
+	if err != nil {
+		return err
+	}
+
+	return c.RunCommand(line)
+}
+
+// Run read and run commands until input is over or exit command is run,
+// errors of commands are shown.
+func (c *Console) Run(msg string) error {
+	for !c.Closed() {
+		err := c.InputAndRunCommand(msg)
+		if err == io.EOF {
+			return nil
+		}
+		if err == errCmdNotFind || err == errUnterminatedQuote {
+			c.Show(fmt.Sprintf("%s\n", err.Error()))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Show print string to output, it is goroutine safe.
+func (c *Console) Show(str string) {
+	c.outM.Lock()
+	defer c.outM.Unlock()
+
+	io.WriteString(c.out, str)
+}
+
+// Input show msg then get a line from input without line break.
+func (c *Console) Input(msg string) (string, error) {
+	if c.editor != nil {
+		return c.editor.readLine(msg)
+	}
+
+	c.Show(msg)
+
+	line, err := c.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Close stop Run after the running command.
+func (c *Console) Close() {
+	c.closeM.Lock()
+	defer c.closeM.Unlock()
+
+	c.closed = true
+}
+
+// Closed ...
+func (c *Console) Closed() bool {
+	c.closeM.RLock()
+	defer c.closeM.RUnlock()
+
+	return c.closed
+}
+
+// AddCommand create a command of the console on stdin.
+func AddCommand(command, summary string, cmdFunc func(paras []string)) {
+	std.AddCommand(command, summary, cmdFunc)
+}
+
+// SetCompleter set the completer of arguments of command of the console on stdin.
+func SetCompleter(command string, completer Completer) {
+	std.SetCompleter(command, completer)
+}
+
+// EnableLineEditing enable line editing of the console on stdin, it fails if stdin
+// is not a terminal.
+func EnableLineEditing(historyFile string) error {
+	fd := os.Stdin.Fd()
+	restore, err := makeRaw(fd)
+	if err != nil {
+		return err
+	}
+	restore()
+
+	if err := std.EnableLineEditing(historyFile); err != nil {
+		return err
+	}
+	std.editor.enter = func() (func(), error) {
+		return makeRaw(fd)
+	}
+	return nil
+}
+
+// InputAndRunCommand get command line from stdin, then run command,
+// If command is not find in comand link list of user and sys, return not find error.
+func InputAndRunCommand(msg string) error {
+	inputStr := Input(msg)
+	return std.RunCommand(inputStr)
+}
+
+// Show print string to stdout synchronously.
 func Show(str string) {
-	strChan <- str
+	std.Show(str)
 }
 
 // Input show msg on the terminal then get string from stdin.
 func Input(msg string) string {
-	Show(msg)
-
-	n, err := os.Stdin.Read(inputBuf)
+	s, err := std.Input(msg)
 	if err != nil {
 		if err == io.EOF {
-			exitFunc(nil)
+			std.exitFunc(nil)
 		}
 		fmt.Printf("Gets Error: %s.", err.Error())
 		os.Exit(1)
 	}
 
-	return string(inputBuf[:n])
+	return s
 }
@@ -0,0 +1,84 @@
+package cmdface
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestConsoleRun(t *testing.T) {
+	var out bytes.Buffer
+	c := NewConsole(strings.NewReader("echo a  b\n\nunknown\nexit\necho never\n"), &out)
+
+	var got []string
+	c.AddCommand("echo", "<words>, show words", func(params []string) {
+		got = append(got, strings.Join(params, ","))
+	})
+
+	if err := c.Run("> "); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != "a,b" {
+		t.Errorf("Params of command are wrong, hope [a,b], get %v.", got)
+	}
+	if !c.Closed() {
+		t.Error("Console should be closed by exit.")
+	}
+	if s := out.String(); !strings.Contains(s, errCmdNotFind.Error()) {
+		t.Errorf("Unknown command should be shown, get %s.", s)
+	}
+}
+
+func TestConsoleHelpAndEOF(t *testing.T) {
+	var out bytes.Buffer
+	c := NewConsole(strings.NewReader("help"), &out)
+	c.AddCommand("echo", "<words>, show words", func(params []string) {})
+
+	// input without line break is run before EOF.
+	if err := c.Run("> "); err != nil {
+		t.Fatal(err)
+	}
+	s := out.String()
+	for _, cmd := range []string{"echo", "help", "exit"} {
+		if !strings.Contains(s, cmd+"\t") {
+			t.Errorf("Help should show command %s, get %s.", cmd, s)
+		}
+	}
+}
+
+func TestServe(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmdface")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l, err := net.Listen("unix", path.Join(dir, "console.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go Serve(l, "> ", func(c *Console) {
+		c.AddCommand("ping", "reply pong", func(params []string) {
+			c.Show("pong\n")
+		})
+	})
+
+	conn, err := net.Dial("unix", path.Join(dir, "console.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("ping\nexit\n"))
+	bs, _ := ioutil.ReadAll(bufio.NewReader(conn))
+	if s := string(bs); s != "> pong\n> " {
+		t.Errorf("Output of remote console is wrong, hope %q, get %q.", "> pong\n> ", s)
+	}
+}
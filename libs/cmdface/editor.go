@@ -0,0 +1,236 @@
+package cmdface
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// keys
+const (
+	keyCtrlA     = 1
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyBackspace = 8
+	keyTab       = 9
+	keyCtrlK     = 11
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// Completer return candidates of the last word of args, args are the words after command,
+// the last one is the word being completed, it may be empty. candidates not starting
+// with the last word are ignored.
+type Completer func(args []string) []string
+
+// lineEditor reads a line key by key, it supports moving cursor, history and completion.
+type lineEditor struct {
+	in   *bufio.Reader
+	show func(string)
+	hist *history
+	// complete return candidates of the last word of line.
+	complete func(line string) []string
+
+	// enter and restore switch terminal into raw mode around reading a line.
+	enter func() (restore func(), err error)
+}
+
+// readLine show prompt then read a line, it returns io.EOF if Ctrl-D is pressed on
+// empty line or input is over.
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	if e.enter != nil {
+		if restore, err := e.enter(); err == nil {
+			defer restore()
+		}
+	}
+
+	var buf []rune
+	pos := 0
+	histPos := len(e.hist.lines)
+	saved := ""
+	lastTab := false
+
+	e.show(prompt)
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			if err == io.EOF && len(buf) > 0 {
+				break
+			}
+			return "", err
+		}
+
+		tab := false
+		switch r {
+		case '\r', '\n':
+			e.show("\n")
+			line := string(buf)
+			e.hist.add(line)
+			return line, nil
+		case keyCtrlD:
+			if len(buf) == 0 {
+				e.show("\n")
+				return "", io.EOF
+			}
+			if pos < len(buf) {
+				buf = append(buf[:pos], buf[pos+1:]...)
+			}
+		case keyCtrlC:
+			e.show("^C\n")
+			buf, pos = nil, 0
+		case keyDelete, keyBackspace:
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+			}
+		case keyCtrlA:
+			pos = 0
+		case keyCtrlE:
+			pos = len(buf)
+		case keyCtrlU:
+			buf, pos = append([]rune(nil), buf[pos:]...), 0
+		case keyCtrlK:
+			buf = buf[:pos]
+		case keyTab:
+			tab = true
+			buf, pos = e.completeLine(buf, pos, lastTab, prompt)
+		case keyEscape:
+			switch e.readEscape() {
+			case 'A': // up
+				if histPos > 0 {
+					if histPos == len(e.hist.lines) {
+						saved = string(buf)
+					}
+					histPos--
+					buf = []rune(e.hist.lines[histPos])
+					pos = len(buf)
+				}
+			case 'B': // down
+				if histPos < len(e.hist.lines) {
+					histPos++
+					if histPos == len(e.hist.lines) {
+						buf = []rune(saved)
+					} else {
+						buf = []rune(e.hist.lines[histPos])
+					}
+					pos = len(buf)
+				}
+			case 'C': // right
+				if pos < len(buf) {
+					pos++
+				}
+			case 'D': // left
+				if pos > 0 {
+					pos--
+				}
+			case 'H': // home
+				pos = 0
+			case 'F': // end
+				pos = len(buf)
+			case '3': // delete
+				if pos < len(buf) {
+					buf = append(buf[:pos], buf[pos+1:]...)
+				}
+			}
+		default:
+			if r < ' ' {
+				break
+			}
+			buf = append(buf, 0)
+			copy(buf[pos+1:], buf[pos:])
+			buf[pos] = r
+			pos++
+		}
+
+		lastTab = tab
+		e.redraw(prompt, buf, pos)
+	}
+
+	line := string(buf)
+	e.hist.add(line)
+	return line, nil
+}
+
+// readEscape read the escape sequence after ESC and return its final key,
+// "ESC [ 3 ~" returns '3'.
+func (e *lineEditor) readEscape() rune {
+	r, _, err := e.in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return 0
+	}
+
+	r, _, err = e.in.ReadRune()
+	if err != nil {
+		return 0
+	}
+	if r >= '0' && r <= '9' {
+		// skip to the end of sequence.
+		for {
+			end, _, err := e.in.ReadRune()
+			if err != nil || end == '~' {
+				break
+			}
+		}
+	}
+	return r
+}
+
+// redraw show the whole line again and put cursor at pos.
+func (e *lineEditor) redraw(prompt string, buf []rune, pos int) {
+	s := "\r" + prompt + string(buf) + "\x1b[K"
+	if back := len(buf) - pos; back > 0 {
+		s += fmt.Sprintf("\x1b[%dD", back)
+	}
+	e.show(s)
+}
+
+// completeLine complete the word before cursor, a unique candidate is completed with
+// a space, otherwise the common prefix of candidates is completed, candidates are shown
+// if tab is pressed twice.
+func (e *lineEditor) completeLine(buf []rune, pos int, lastTab bool, prompt string) ([]rune, int) {
+	if e.complete == nil {
+		return buf, pos
+	}
+
+	head := string(buf[:pos])
+	word := head[strings.LastIndexAny(head, " \t")+1:]
+	var candidates []string
+	for _, c := range e.complete(head) {
+		if strings.HasPrefix(c, word) {
+			candidates = append(candidates, c)
+		}
+	}
+	if len(candidates) == 0 {
+		return buf, pos
+	}
+
+	sort.Strings(candidates)
+	completion := candidates[0]
+	if len(candidates) == 1 {
+		completion += " "
+	} else {
+		completion = commonPrefix(candidates)
+		if completion == word && lastTab {
+			e.show("\n" + strings.Join(candidates, "  ") + "\n")
+		}
+	}
+
+	insert := []rune(completion[len(word):])
+	tail := append(insert, buf[pos:]...)
+	return append(buf[:pos:pos], tail...), pos + len(insert)
+}
+
+// commonPrefix return the longest common prefix of ss.
+func commonPrefix(ss []string) string {
+	prefix := ss[0]
+	for _, s := range ss[1:] {
+		for !strings.HasPrefix(s, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,95 @@
+package cmdface
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// newTestConsole create a console with line editing on input.
+func newTestConsole(t *testing.T, input string) (*Console, *bytes.Buffer) {
+	var out bytes.Buffer
+	c := NewConsole(strings.NewReader(input), &out)
+	c.AddCommand("sci", "<rid>, join a room", func(params []string) {})
+	c.AddCommand("say", "<message>, chat", func(params []string) {})
+	c.AddCommand("srl", "[sub], query room list", func(params []string) {})
+	c.SetCompleter("srl", func(args []string) []string {
+		return []string{"sub", "subscribe"}
+	})
+	if err := c.EnableLineEditing(""); err != nil {
+		t.Fatal(err)
+	}
+	return c, &out
+}
+
+// readLines read all lines from console.
+func readLines(c *Console) []string {
+	var lines []string
+	for {
+		line, err := c.Input("> ")
+		if err != nil {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestLineEditorEditing(t *testing.T) {
+	input := strings.Join([]string{
+		"sco\x7fi 1\r",                      // backspace
+		"ay\x1b[D\x1b[Ds\x1b[F 2\r",         // left arrow and insert
+		"sci 3\x01\x0b\r",                   // Ctrl-A then Ctrl-K
+		"xx\x15sci 4\x1b[H\x1b[3~\x1b[Fz\r", // Ctrl-U, home, delete and end
+	}, "")
+	c, _ := newTestConsole(t, input)
+
+	hope := []string{"sci 1", "say 2", "", "ci 4z"}
+	lines := readLines(c)
+	if strings.Join(lines, "|") != strings.Join(hope, "|") {
+		t.Errorf("Lines are wrong, hope %q, get %q.", hope, lines)
+	}
+}
+
+func TestLineEditorHistory(t *testing.T) {
+	// up twice, down once, then run; up on the new line then edit it.
+	input := "sci 1\rsci 2\r\x1b[A\x1b[A\x1b[B\r\x1b[A9\r"
+	c, _ := newTestConsole(t, input)
+
+	hope := []string{"sci 1", "sci 2", "sci 2", "sci 29"}
+	lines := readLines(c)
+	if strings.Join(lines, "|") != strings.Join(hope, "|") {
+		t.Errorf("Lines are wrong, hope %q, get %q.", hope, lines)
+	}
+	if n := len(c.editor.hist.lines); n != 3 {
+		t.Errorf("Number of history lines is wrong, hope %d, get %d.", 3, n)
+	}
+}
+
+func TestLineEditorCompletion(t *testing.T) {
+	// unique command, common prefix of commands, then arguments.
+	input := "sr\t\r" + "s\t\t\r" + "srl s\t\r" + "srl subs\t\r"
+	c, out := newTestConsole(t, input)
+
+	hope := []string{"srl ", "s", "srl sub", "srl subscribe "}
+	lines := readLines(c)
+	if strings.Join(lines, "|") != strings.Join(hope, "|") {
+		t.Errorf("Lines are wrong, hope %q, get %q.", hope, lines)
+	}
+	// candidates are shown after pressing tab twice.
+	if !strings.Contains(out.String(), "say  sci  source  srl\n") {
+		t.Errorf("Candidates should be shown, get %q.", out.String())
+	}
+}
+
+func TestLineEditorEOF(t *testing.T) {
+	c, _ := newTestConsole(t, "\x04")
+	if _, err := c.Input("> "); err != io.EOF {
+		t.Errorf("Ctrl-D on empty line should return EOF, get %v.", err)
+	}
+
+	c, _ = newTestConsole(t, "sci 1")
+	if line, err := c.Input("> "); err != nil || line != "sci 1" {
+		t.Errorf("Line before EOF is wrong, get %q, %v.", line, err)
+	}
+}
@@ -0,0 +1,66 @@
+package cmdface
+
+import (
+	"bufio"
+	"os"
+)
+
+// historySize limit the number of lines kept in history.
+const historySize = 500
+
+// history keeps command lines inputted, lines are appended to file if it is set.
+type history struct {
+	lines []string
+	file  string
+}
+
+// newHistory create history and load lines from file, file is created if it is not exist.
+// empty file keeps history in memory only.
+func newHistory(file string) (*history, error) {
+	h := &history{file: file}
+	if file == "" {
+		return h, nil
+	}
+
+	f, err := os.OpenFile(file, os.O_RDONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		h.push(scanner.Text())
+	}
+	return h, scanner.Err()
+}
+
+// push put line into memory, empty line and line same as the last one are skipped.
+// it returns false if line is skipped.
+func (h *history) push(line string) bool {
+	if line == "" || (len(h.lines) > 0 && h.lines[len(h.lines)-1] == line) {
+		return false
+	}
+
+	if len(h.lines) >= historySize {
+		h.lines = append(h.lines[:0], h.lines[len(h.lines)-historySize+1:]...)
+	}
+	h.lines = append(h.lines, line)
+	return true
+}
+
+// add put line into history and append it to file.
+func (h *history) add(line string) error {
+	if !h.push(line) || h.file == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(h.file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(line + "\n")
+	return err
+}
@@ -0,0 +1,44 @@
+package cmdface
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmdface")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := path.Join(dir, "history")
+
+	h, err := newHistory(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"sci 1", "sci 1", "", "pkgs"} {
+		h.add(line)
+	}
+	if len(h.lines) != 2 {
+		t.Errorf("Number of lines is wrong, hope %d, get %d.", 2, len(h.lines))
+	}
+
+	// history is loaded from file.
+	h, err = newHistory(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.lines) != 2 || h.lines[0] != "sci 1" || h.lines[1] != "pkgs" {
+		t.Errorf("Loaded lines are wrong, get %q.", h.lines)
+	}
+
+	for i := 0; i < historySize+10; i++ {
+		h.push(string(rune('a' + i%26)))
+	}
+	if len(h.lines) != historySize {
+		t.Errorf("Number of lines is wrong, hope %d, get %d.", historySize, len(h.lines))
+	}
+}
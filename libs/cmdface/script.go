@@ -0,0 +1,248 @@
+package cmdface
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultWaitTimeout is used by wait directive without timeout.
+	defaultWaitTimeout = time.Second * 5
+	// maxEvents limit the number of events kept, the oldest one is dropped.
+	maxEvents = 256
+	// maxScriptDepth limit scripts running scripts by source, so a script sourcing
+	// itself does not recurse forever.
+	maxScriptDepth = 16
+)
+
+var errScriptTooDeep = fmt.Errorf("Scripts are nested deeper than %d.", maxScriptDepth)
+
+// scriptState keeps variables and events of a console for scripts.
+type scriptState struct {
+	varsM sync.RWMutex
+	vars  map[string]string
+
+	// events emitted since the last command started, wait directive looks for them.
+	eventsM sync.Mutex
+	events  []string
+	emitted *sync.Cond
+
+	// depth is the number of scripts running, atomic.
+	depth int32
+}
+
+// newScriptState ...
+func newScriptState() *scriptState {
+	ss := &scriptState{vars: make(map[string]string)}
+	ss.emitted = sync.NewCond(&ss.eventsM)
+	return ss
+}
+
+// SetVar set a variable used as $name or ${name} in scripts.
+func (c *Console) SetVar(name, value string) {
+	c.script.varsM.Lock()
+	defer c.script.varsM.Unlock()
+
+	c.script.vars[name] = value
+}
+
+// Var return the value of variable.
+func (c *Console) Var(name string) (string, bool) {
+	c.script.varsM.RLock()
+	defer c.script.varsM.RUnlock()
+
+	v, ok := c.script.vars[name]
+	return v, ok
+}
+
+// Emit record an event such as a received message, wait directives in scripts are
+// waiting for it.
+func (c *Console) Emit(event string) {
+	ss := c.script
+	ss.eventsM.Lock()
+	defer ss.eventsM.Unlock()
+
+	if len(ss.events) >= maxEvents {
+		ss.events = append(ss.events[:0], ss.events[1:]...)
+	}
+	ss.events = append(ss.events, event)
+	ss.emitted.Broadcast()
+}
+
+// clearEvents forget events emitted before, it is called before running a command.
+func (c *Console) clearEvents() {
+	c.script.eventsM.Lock()
+	defer c.script.eventsM.Unlock()
+
+	c.script.events = c.script.events[:0]
+}
+
+// waitEvent wait until event is emitted after the last command started, or timeout.
+func (c *Console) waitEvent(event string, timeout time.Duration) error {
+	ss := c.script
+	timer := time.AfterFunc(timeout, func() {
+		ss.eventsM.Lock()
+		defer ss.eventsM.Unlock()
+		ss.emitted.Broadcast()
+	})
+	defer timer.Stop()
+
+	deadline := time.Now().Add(timeout)
+	ss.eventsM.Lock()
+	defer ss.eventsM.Unlock()
+	for {
+		for i, e := range ss.events {
+			if e == event {
+				// one event satisfies one wait.
+				ss.events = append(ss.events[:i], ss.events[i+1:]...)
+				return nil
+			}
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("Timeout for waiting '%s'.", event)
+		}
+		ss.emitted.Wait()
+	}
+}
+
+// expandVars replace $name and ${name} in line with variables, $$ is $.
+func (c *Console) expandVars(line string) (string, error) {
+	var err error
+	expanded := os.Expand(strings.Replace(line, "$$", "${$}", -1), func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		v, ok := c.Var(name)
+		if !ok && err == nil {
+			err = fmt.Errorf("Undefined variable $%s.", name)
+		}
+		return v
+	})
+	return expanded, err
+}
+
+// RunScript run commands from r line by line, it stops at the first error.
+//
+// lines starting with '#' are comments, variables are expanded in every line, and
+// the following directives are supported besides commands:
+//
+//	sleep <duration>            sleep for duration such as 500ms
+//	wait <event> [timeout]      wait for an event emitted after the last command, 5s by default
+//	set <name> <value>          set a variable
+func (c *Console) RunScript(r io.Reader) error {
+	if atomic.AddInt32(&c.script.depth, 1) > maxScriptDepth {
+		atomic.AddInt32(&c.script.depth, -1)
+		return errScriptTooDeep
+	}
+	defer atomic.AddInt32(&c.script.depth, -1)
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		if err := c.runScriptLine(scanner.Text()); err != nil {
+			return fmt.Errorf("Line %d: %v", n, err)
+		}
+		if c.Closed() {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// RunScriptFile run commands from file, see RunScript.
+func (c *Console) RunScriptFile(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return c.RunScript(f)
+}
+
+// runScriptLine ...
+func (c *Console) runScriptLine(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	line, err := c.expandVars(line)
+	if err != nil {
+		return err
+	}
+	args, err := splitArgs(line)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		// such as a line of empty variable.
+		return nil
+	}
+
+	switch args[0] {
+	case "sleep":
+		if len(args) != 2 {
+			return errors.New("Usage: sleep <duration>")
+		}
+		d, err := time.ParseDuration(args[1])
+		if err != nil {
+			return err
+		}
+		time.Sleep(d)
+		return nil
+	case "wait":
+		if len(args) < 2 || len(args) > 3 {
+			return errors.New("Usage: wait <event> [timeout]")
+		}
+		timeout := defaultWaitTimeout
+		if len(args) == 3 {
+			if timeout, err = time.ParseDuration(args[2]); err != nil {
+				return err
+			}
+		}
+		return c.waitEvent(args[1], timeout)
+	case "set":
+		if len(args) != 3 {
+			return errors.New("Usage: set <name> <value>")
+		}
+		c.SetVar(args[1], args[2])
+		return nil
+	}
+
+	c.Show(line + "\n")
+	c.clearEvents()
+	return c.RunCommand(line)
+}
+
+// sourceFunc is the sys command to run a script.
+func (c *Console) sourceFunc(params []string) {
+	if len(params) != 1 {
+		c.Show("Usage: source <file>\n")
+		return
+	}
+	if err := c.RunScriptFile(params[0]); err != nil {
+		c.Show(fmt.Sprintf("%s\n", err.Error()))
+	}
+}
+
+// SetVar set a variable of the console on stdin.
+func SetVar(name, value string) {
+	std.SetVar(name, value)
+}
+
+// Emit record an event of the console on stdin.
+func Emit(event string) {
+	std.Emit(event)
+}
+
+// RunScriptFile run a script on the console on stdin.
+func RunScriptFile(filename string) error {
+	return std.RunScriptFile(filename)
+}
@@ -0,0 +1,119 @@
+package cmdface
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunScript(t *testing.T) {
+	var out bytes.Buffer
+	c := NewConsole(strings.NewReader(""), &out)
+
+	var got []string
+	c.AddCommand("sci", "<rid>, join a room", func(params []string) {
+		got = append(got, "sci "+strings.Join(params, ","))
+		// server replies after a while.
+		go func() {
+			time.Sleep(time.Millisecond * 10)
+			c.Emit("connected info")
+		}()
+	})
+	c.AddCommand("say", "<message>, chat", func(params []string) {
+		got = append(got, "say "+strings.Join(params, ","))
+	})
+	c.SetVar("uid", "42")
+
+	script := `
+# join room then chat
+set rid 3
+sci $rid
+wait "connected info" 1s
+sleep 1ms
+say "user ${uid} costs $$5"
+`
+	if err := c.RunScript(strings.NewReader(script)); err != nil {
+		t.Fatal(err)
+	}
+
+	hope := []string{"sci 3", "say user 42 costs $5"}
+	if strings.Join(got, "|") != strings.Join(hope, "|") {
+		t.Errorf("Commands are wrong, hope %q, get %q.", hope, got)
+	}
+	if !strings.Contains(out.String(), "sci 3\n") {
+		t.Errorf("Commands should be shown, get %s.", out.String())
+	}
+}
+
+func TestRunScriptErrors(t *testing.T) {
+	cases := map[string]string{
+		"say $nobody":          "Line 1: Undefined variable $nobody.",
+		"\nunknown":            "Line 2: Command Not Find.",
+		"wait message 10ms":    "Line 1: Timeout for waiting 'message'.",
+		"sleep forever":        "Line 1: time: invalid duration",
+		"say 'hello":           "Line 1: Unterminated quote.",
+		"set uid":              "Line 1: Usage: set <name> <value>",
+		"say 1\nexit\nunknown": "",
+		"set cmd \"\"\n$cmd":   "",
+	}
+
+	for script, hope := range cases {
+		c := NewConsole(strings.NewReader(""), &bytes.Buffer{})
+		c.AddCommand("say", "<message>, chat", func(params []string) {})
+
+		err := c.RunScript(strings.NewReader(script))
+		if hope == "" {
+			if err != nil {
+				t.Errorf("Script %q should succeed, get %v.", script, err)
+			}
+			continue
+		}
+		if err == nil || !strings.HasPrefix(err.Error(), hope) {
+			t.Errorf("Error of script %q is wrong, hope %s, get %v.", script, hope, err)
+		}
+	}
+}
+
+func TestWaitEventEmittedBefore(t *testing.T) {
+	c := NewConsole(strings.NewReader(""), &bytes.Buffer{})
+
+	// events emitted before the last command are ignored.
+	c.Emit("connected info")
+	c.AddCommand("sci", "<rid>, join a room", func(params []string) {
+		c.Emit("gameover info")
+	})
+	if err := c.RunScript(strings.NewReader("sci 1\nwait \"connected info\" 10ms")); err == nil {
+		t.Error("Event emitted before command should be ignored.")
+	}
+	if err := c.RunScript(strings.NewReader("sci 1\nwait \"gameover info\" 10ms")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunScriptSourceItself(t *testing.T) {
+	f, err := ioutil.TempFile("", "script")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("say once\nsource " + f.Name() + "\n")
+	f.Close()
+
+	var out bytes.Buffer
+	c := NewConsole(strings.NewReader(""), &out)
+	n := 0
+	c.AddCommand("say", "<message>, chat", func(params []string) { n++ })
+
+	if err := c.RunScriptFile(f.Name()); err != nil {
+		t.Fatal(err)
+	}
+	if n != maxScriptDepth {
+		t.Errorf("Number of nested scripts is wrong, hope %d, get %d.", maxScriptDepth, n)
+	}
+	if !strings.Contains(out.String(), errScriptTooDeep.Error()) {
+		t.Errorf("Nesting too deep should be shown, get %s.", out.String())
+	}
+}
@@ -0,0 +1,27 @@
+package cmdface
+
+import (
+	"fmt"
+	"net"
+)
+
+// Serve accept connections on l and run a console for each connection until it exits
+// or disconnects, setup is called to register commands for every new console.
+func Serve(l net.Listener, msg string, setup func(c *Console)) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			defer conn.Close()
+
+			c := NewConsole(conn, conn)
+			setup(c)
+			if err := c.Run(msg); err != nil {
+				c.Show(fmt.Sprintf("%s\n", err.Error()))
+			}
+		}()
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package cmdface
+
+import "errors"
+
+// makeRaw is only supported on linux, consoles fall back to reading lines.
+func makeRaw(fd uintptr) (restore func(), err error) {
+	return nil, errors.New("Line editing is not supported on this system.")
+}
@@ -0,0 +1,306 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// DropPolicy decides what to do when the buffer of async logger is full.
+type DropPolicy byte
+
+// drop policies
+const (
+	// DropNewest discards the log being written.
+	DropNewest DropPolicy = iota
+	// DropOldest discards the oldest log in buffer to make room for the new one.
+	DropOldest
+	// Block waits until the buffer has room, it never loses logs but may stall the invoker.
+	Block
+)
+
+// defaultBufferSize is used when AsyncOptions.BufferSize is not positive.
+const defaultBufferSize = 1024
+
+// AsyncOptions configures the async mode of logger.
+type AsyncOptions struct {
+	// BufferSize is the number of logs waiting to be written.
+	BufferSize int
+	// Policy is used when the buffer is full.
+	Policy DropPolicy
+	// DedupWindow is the duration the same message from the same callsite is counted
+	// instead of written, then "last message repeated N times" is written. 0 disables it.
+	DedupWindow time.Duration
+	// SampleFirst is the number of logs written per callsite per second, after which only
+	// every SampleThereafter-th log is written. 0 disables sampling.
+	SampleFirst      int
+	SampleThereafter int
+}
+
+// record is an encoded log, or a flush marker if flushed is not nil.
+type record struct {
+	w       io.Writer
+	bs      []byte
+	flushed chan struct{}
+}
+
+// callsite is the state of logs printed at the same file and line.
+type callsite struct {
+	// dedup
+	last     *entry
+	content  string
+	since    time.Time
+	repeated int
+
+	// sampling
+	second int64
+	count  int
+}
+
+// asyncWriter writes records in its own goroutine.
+type asyncWriter struct {
+	opts  AsyncOptions
+	queue chan record
+	// critical is unbuffered, records and flush markers sent through it are never dropped,
+	// records queued before them are written first.
+	critical chan record
+
+	dropped  uint64 // atomic
+	reported uint64 // only be used by writing goroutine
+
+	// guarded by the lock of sink.
+	sites map[string]*callsite
+}
+
+// EnableAsync switch logger into async mode, logs are written by a background goroutine,
+// so invoker never waits for slow outputs. it also changes children and parent, only the
+// first call works.
+func (l *TwoOutputLogger) EnableAsync(opts AsyncOptions) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.async != nil {
+		return
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultBufferSize
+	}
+
+	l.async = &asyncWriter{
+		opts:     opts,
+		queue:    make(chan record, opts.BufferSize),
+		critical: make(chan record),
+		sites:    make(map[string]*callsite),
+	}
+	go l.writeLoop(l.async)
+}
+
+// Flush write repeated counts and wait until all buffered logs are written.
+// it does nothing in sync mode.
+func (l *TwoOutputLogger) Flush() {
+	l.m.Lock()
+	a := l.async
+	var records []record
+	if a != nil {
+		records = l.records(a.sweep(time.Now(), true))
+	}
+	l.m.Unlock()
+
+	if a == nil {
+		return
+	}
+	for _, r := range records {
+		a.push(r, true)
+	}
+	a.flush()
+}
+
+// Dropped return the number of logs dropped by full buffer or sampling.
+func (l *TwoOutputLogger) Dropped() uint64 {
+	l.m.RLock()
+	a := l.async
+	l.m.RUnlock()
+
+	if a == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// records encode entries into records, it must be called with lock.
+func (s *sink) records(entries []*entry) []record {
+	records := make([]record, 0, len(entries))
+	for _, e := range entries {
+		w := s.encode(e)
+		bs := make([]byte, len(s.buf))
+		copy(bs, s.buf)
+		records = append(records, record{w: w, bs: bs})
+	}
+	return records
+}
+
+// writeLoop write records in order, repeated counts and the number of dropped logs are
+// written periodically.
+func (s *sink) writeLoop(a *asyncWriter) {
+	interval := a.opts.DedupWindow
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case r := <-a.queue:
+			writeRecord(r)
+		case r := <-a.critical:
+			// only records queued before r are written, so the loop ends even if
+			// the queue is refilled all the time.
+			for n := len(a.queue); n > 0; n-- {
+				writeRecord(<-a.queue)
+			}
+			if r.flushed != nil {
+				close(r.flushed)
+				continue
+			}
+			writeRecord(r)
+		case now := <-ticker.C:
+			s.m.Lock()
+			records := s.records(a.sweep(now, false))
+			if dropped := atomic.LoadUint64(&a.dropped); dropped > a.reported {
+				e := &entry{
+					time:  now,
+					file:  "log",
+					level: WarnLevel,
+					msg:   fmt.Sprintf("%d logs are dropped. \n", dropped-a.reported),
+				}
+				records = append(records, s.records([]*entry{e})...)
+				a.reported = dropped
+			}
+			s.m.Unlock()
+
+			for _, r := range records {
+				writeRecord(r)
+			}
+		}
+	}
+}
+
+// writeRecord write r, errors are printed to stderr because there is no way to return it.
+func writeRecord(r record) {
+	if _, err := r.w.Write(r.bs); err != nil {
+		os.Stderr.WriteString("Failed to write log: " + err.Error() + "\n")
+	}
+}
+
+// push put r into queue according to drop policy, r is never dropped if block is true.
+func (a *asyncWriter) push(r record, block bool) {
+	if block {
+		a.critical <- r
+		return
+	}
+	if a.opts.Policy == Block {
+		a.queue <- r
+		return
+	}
+
+	for {
+		select {
+		case a.queue <- r:
+			return
+		default:
+		}
+
+		if a.opts.Policy != DropOldest {
+			atomic.AddUint64(&a.dropped, 1)
+			return
+		}
+
+		select {
+		case <-a.queue:
+			atomic.AddUint64(&a.dropped, 1)
+		default:
+		}
+	}
+}
+
+// flush wait until all records pushed before are written.
+func (a *asyncWriter) flush() {
+	flushed := make(chan struct{})
+	a.critical <- record{flushed: flushed}
+	<-flushed
+}
+
+// filter deduplicate and sample e by its callsite, it returns entries should be written,
+// which may include the repeated count of last message. it must be called with lock.
+func (a *asyncWriter) filter(e *entry) []*entry {
+	key := e.file + ":" + strconv.Itoa(e.line)
+	c, ok := a.sites[key]
+	if !ok {
+		c = &callsite{}
+		a.sites[key] = c
+	}
+
+	var entries []*entry
+	if a.opts.DedupWindow > 0 {
+		content := e.msg + fmt.Sprint(e.fields)
+		if c.last != nil && c.content == content && e.time.Sub(c.since) < a.opts.DedupWindow {
+			c.repeated++
+			return nil
+		}
+
+		if r := c.report(e.time); r != nil {
+			entries = append(entries, r)
+		}
+		c.last, c.content, c.since = e, content, e.time
+	}
+
+	if a.opts.SampleFirst > 0 {
+		if second := e.time.Unix(); second != c.second {
+			c.second, c.count = second, 0
+		}
+		c.count++
+
+		n := c.count - a.opts.SampleFirst
+		if n > 0 && (a.opts.SampleThereafter <= 0 || n%a.opts.SampleThereafter != 0) {
+			atomic.AddUint64(&a.dropped, 1)
+			return entries
+		}
+	}
+
+	return append(entries, e)
+}
+
+// sweep return repeated counts of callsites whose dedup window is over, all repeated counts
+// are returned if force is true. it must be called with lock.
+func (a *asyncWriter) sweep(now time.Time, force bool) []*entry {
+	var entries []*entry
+	for _, c := range a.sites {
+		if c.repeated == 0 {
+			continue
+		}
+		if !force && now.Sub(c.since) < a.opts.DedupWindow {
+			continue
+		}
+		entries = append(entries, c.report(now))
+		c.last = nil
+	}
+	return entries
+}
+
+// report return "last message repeated N times" entry and reset the count,
+// it returns nil if last message is not repeated.
+func (c *callsite) report(now time.Time) *entry {
+	if c.repeated == 0 {
+		return nil
+	}
+
+	r := *c.last
+	r.time = now
+	r.msg = fmt.Sprintf("Last message repeated %d times. \n", c.repeated)
+	c.repeated = 0
+	return &r
+}
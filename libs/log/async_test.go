@@ -0,0 +1,157 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a goroutine safe bytes.Buffer.
+type lockedBuffer struct {
+	m   sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.buf.String()
+}
+
+// blockingWriter blocks writing until release is closed.
+type blockingWriter struct {
+	lockedBuffer
+	release chan struct{}
+}
+
+func (b *blockingWriter) Write(p []byte) (int, error) {
+	<-b.release
+	return b.lockedBuffer.Write(p)
+}
+
+func TestAsyncLoggerFlush(t *testing.T) {
+	var testBuffer lockedBuffer
+	l := NewSimpleLogger(&testBuffer, InfoLevel)
+	l.EnableAsync(AsyncOptions{BufferSize: 100})
+
+	for i := 0; i < 10; i++ {
+		l.Infof("testing_info %d\n", i)
+	}
+	l.Flush()
+
+	if n := strings.Count(testBuffer.String(), "testing_info"); n != 10 {
+		t.Errorf("Number of written logs is wrong, hope %d, get %d.", 10, n)
+	}
+}
+
+func TestAsyncLoggerDropPolicy(t *testing.T) {
+	for _, policy := range []DropPolicy{DropNewest, DropOldest} {
+		w := &blockingWriter{release: make(chan struct{})}
+		l := NewSimpleLogger(w, InfoLevel)
+		l.EnableAsync(AsyncOptions{BufferSize: 2, Policy: policy})
+
+		// the first log may be taken by writing goroutine and blocked there.
+		for i := 0; i < 10; i++ {
+			l.Infof("testing_info %d\n", i)
+		}
+		dropped := l.Dropped()
+		close(w.release)
+		l.Flush()
+
+		if dropped < 7 || dropped > 8 {
+			t.Errorf("Number of dropped logs is wrong, hope 7 or 8, get %d.", dropped)
+		}
+		s := w.String()
+		if policy == DropOldest && !strings.Contains(s, "testing_info 9\n") {
+			t.Errorf("DropOldest should keep the newest log, get %s.", s)
+		}
+		if policy == DropNewest && strings.Contains(s, "testing_info 9\n") {
+			t.Errorf("DropNewest should drop the newest log, get %s.", s)
+		}
+	}
+}
+
+func TestAsyncLoggerDedup(t *testing.T) {
+	var testBuffer lockedBuffer
+	l := NewSimpleLogger(&testBuffer, InfoLevel)
+	l.EnableAsync(AsyncOptions{BufferSize: 1000, DedupWindow: time.Hour})
+
+	// all logs come from the same callsite.
+	logs := make([]string, 0, 505)
+	for i := 0; i < 501; i++ {
+		logs = append(logs, "Bad client.")
+	}
+	logs = append(logs, "Another message.", "Bad client.")
+	for _, msg := range logs {
+		l.Errorf("%s \n", msg)
+	}
+	for i := 0; i < 3; i++ {
+		l.With(Fields{"uid": i}).Errorf("Bad client. \n")
+	}
+	l.Flush()
+
+	s := testBuffer.String()
+	if n := strings.Count(s, "Bad client."); n != 5 {
+		t.Errorf("Number of written logs is wrong, hope %d, get %d.", 5, n)
+	}
+	if !strings.Contains(s, "Last message repeated 500 times.") {
+		t.Errorf("Repeated count is wrong, get %s.", s)
+	}
+	if strings.Index(s, "Last message repeated 500 times.") > strings.Index(s, "Another message.") {
+		t.Errorf("Repeated count should be written before next message, get %s.", s)
+	}
+}
+
+func TestAsyncLoggerSampling(t *testing.T) {
+	var testBuffer lockedBuffer
+	l := NewSimpleLogger(&testBuffer, InfoLevel)
+	l.EnableAsync(AsyncOptions{BufferSize: 1000, SampleFirst: 10, SampleThereafter: 100})
+
+	// distinct messages are not deduplicated but sampled.
+	start := time.Now()
+	for i := 0; i < 300; i++ {
+		l.Warnf("Client Message Error %d. \n", i)
+	}
+	l.Flush()
+	if time.Since(start) > time.Millisecond*500 {
+		t.Skip("Too slow to log in one second.")
+	}
+
+	n := strings.Count(testBuffer.String(), "Client Message Error")
+	if n < 12 || n > 14 {
+		t.Errorf("Number of sampled logs is wrong, hope about %d, get %d.", 12, n)
+	}
+}
+
+func TestAsyncLoggerCriticalNeverDropped(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	l := NewSimpleLogger(w, InfoLevel)
+	l.EnableAsync(AsyncOptions{BufferSize: 1, Policy: DropOldest})
+	a := l.async
+
+	pushed := make(chan struct{})
+	go func() {
+		a.push(record{w: w, bs: []byte("critical\n")}, true)
+		close(pushed)
+	}()
+
+	// non-critical logs evict each other but not the critical one.
+	for i := 0; i < 10; i++ {
+		l.Infof("testing_info %d\n", i)
+	}
+	close(w.release)
+	<-pushed
+	l.Flush()
+
+	if s := w.String(); !strings.Contains(s, "critical\n") {
+		t.Errorf("Critical log should never be dropped, get %s.", s)
+	}
+}
@@ -0,0 +1,155 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is the format of logs.
+type Format byte
+
+// formats
+const (
+	// TextFormat is the colored text for reading by human, it is the default format.
+	TextFormat Format = iota
+	// JSONFormat prints one json object per line for log pipeline.
+	JSONFormat
+)
+
+// levelNames is the level of logs in JSONFormat.
+var levelNames = [...]string{
+	DebugLevel: "debug",
+	InfoLevel:  "info",
+	WarnLevel:  "warn",
+	ErrorLevel: "error",
+	PanicLevel: "panic",
+	FatalLevel: "fatal",
+}
+
+// reserved keys of JSONFormat, fields with the same key are renamed to "fields.key".
+var reservedKeys = map[string]bool{
+	"time":   true,
+	"level":  true,
+	"logger": true,
+	"caller": true,
+	"msg":    true,
+}
+
+var errInvalidLevel = errors.New("Invalid log level.")
+
+// LevelName return the name of level, such as "info".
+func LevelName(level byte) string {
+	if int(level) >= len(levelNames) {
+		return "unknown"
+	}
+	return levelNames[level]
+}
+
+// ParseLevel parse the name of level, it is case insensitive.
+func ParseLevel(name string) (byte, error) {
+	name = strings.ToLower(name)
+	for level, n := range levelNames {
+		if n == name {
+			return byte(level), nil
+		}
+	}
+	return 0, errInvalidLevel
+}
+
+// entry is a log waiting to be encoded.
+type entry struct {
+	time   time.Time
+	file   string
+	line   int
+	level  byte
+	name   string
+	msg    string
+	fields Fields
+}
+
+// sortedKeys return keys of fields in order, so the same fields are always printed the same.
+func sortedKeys(fields Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// encodeText encode e into buf as " date clock filePosition - levelPrefix msg key=value\n".
+// msg is kept as it is if there is no field.
+func encodeText(buf *[]byte, e *entry) {
+	generateLogHead(buf, e.time, e.file, e.line, coloredPrefixes[e.level])
+	if len(e.fields) == 0 {
+		*buf = append(*buf, e.msg...)
+		return
+	}
+
+	*buf = append(*buf, strings.TrimRight(e.msg, " \n")...)
+	for _, k := range sortedKeys(e.fields) {
+		*buf = append(*buf, ' ')
+		*buf = append(*buf, k...)
+		*buf = append(*buf, '=')
+		v := fmt.Sprint(e.fields[k])
+		if strings.ContainsAny(v, " =\"\n") {
+			v = strconv.Quote(v)
+		}
+		*buf = append(*buf, v...)
+	}
+	*buf = append(*buf, '\n')
+}
+
+// encodeJSON encode e into buf as a json object in one line.
+func encodeJSON(buf *[]byte, e *entry) {
+	*buf = (*buf)[:0]
+
+	*buf = append(*buf, `{"time":`...)
+	*buf = strconv.AppendQuote(*buf, e.time.Format(time.RFC3339Nano))
+	*buf = append(*buf, `,"level":`...)
+	*buf = strconv.AppendQuote(*buf, levelNames[e.level])
+	if e.name != "" {
+		*buf = append(*buf, `,"logger":`...)
+		*buf = strconv.AppendQuote(*buf, e.name)
+	}
+	*buf = append(*buf, `,"caller":"`...)
+	*buf = append(*buf, e.file...)
+	*buf = append(*buf, ':')
+	itoa(buf, e.line, -1)
+	*buf = append(*buf, `","msg":`...)
+	*buf = appendJSONValue(*buf, strings.TrimRight(e.msg, " \n"))
+
+	for _, k := range sortedKeys(e.fields) {
+		key := k
+		if reservedKeys[k] {
+			key = "fields." + k
+		}
+		*buf = append(*buf, ',')
+		*buf = appendJSONValue(*buf, key)
+		*buf = append(*buf, ':')
+		*buf = appendJSONValue(*buf, e.fields[k])
+	}
+	*buf = append(*buf, "}\n"...)
+}
+
+// appendJSONValue append v as json, errors and Stringers are printed as string,
+// the value which can't be marshaled is printed by fmt.
+func appendJSONValue(buf []byte, v interface{}) []byte {
+	switch x := v.(type) {
+	case error:
+		v = x.Error()
+	case fmt.Stringer:
+		v = x.String()
+	}
+
+	bs, err := json.Marshal(v)
+	if err != nil {
+		bs, _ = json.Marshal(fmt.Sprint(v))
+	}
+	return append(buf, bs...)
+}
@@ -0,0 +1,120 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoggerDebugLevel(t *testing.T) {
+	var testBuffer bytes.Buffer
+	l := NewSimpleLogger(&testBuffer, InfoLevel)
+
+	l.Debugln("testing_debug")
+	if testBuffer.Len() != 0 {
+		t.Errorf("Debugln: debug log should be filtered, but get %s.", testBuffer.String())
+	}
+
+	l.SetMinLevel(DebugLevel)
+	l.Debugf("testing_debug %d\n", 1)
+	debug := "testing_debug 1\n"
+	if tbs := testBuffer.String(); !strings.Contains(tbs, debug) || !strings.Contains(tbs, debugPrefix) {
+		t.Errorf("Debugf: the end of printed string should be %s, but get %s.", debug, tbs)
+	}
+}
+
+func TestLoggerWith(t *testing.T) {
+	var testBuffer bytes.Buffer
+	l := NewSimpleLogger(&testBuffer, InfoLevel)
+
+	child := l.With(Fields{"uid": 3, "rid": 1})
+	child.Infof("User joins room. \n")
+	info := "User joins room. rid=1 uid=3\n"
+	if tbs := testBuffer.String(); !strings.HasSuffix(tbs, info) {
+		t.Errorf("With: the end of printed string should be %s, but get %s.", info, tbs)
+	}
+
+	testBuffer.Reset()
+	child.With(Fields{"rid": 2, "reason": "idle too long"}).Warnln("User left room.")
+	warn := `User left room. reason="idle too long" rid=2 uid=3` + "\n"
+	if tbs := testBuffer.String(); !strings.HasSuffix(tbs, warn) {
+		t.Errorf("With: the end of printed string should be %s, but get %s.", warn, tbs)
+	}
+
+	// parent is not changed by child.
+	testBuffer.Reset()
+	l.Infoln("no fields")
+	if tbs := testBuffer.String(); strings.Contains(tbs, "uid=") {
+		t.Errorf("With: parent should not print fields of child, but get %s.", tbs)
+	}
+
+	// children share the min level of parent.
+	testBuffer.Reset()
+	l.SetMinLevel(ErrorLevel)
+	child.Infoln("filtered")
+	if testBuffer.Len() != 0 {
+		t.Errorf("With: child should share min level, but get %s.", testBuffer.String())
+	}
+}
+
+func TestLoggerJSONFormat(t *testing.T) {
+	var testBuffer bytes.Buffer
+	l := NewSimpleLogger(&testBuffer, InfoLevel)
+	l.SetFormat(JSONFormat)
+
+	l.With(Fields{
+		"uid":  7,
+		"err":  errors.New("Bad message."),
+		"rtt":  time.Millisecond * 20,
+		"msg":  "shadowed",
+		"type": "chat",
+	}).Warnf("Client Message Error: %s \n", "wrong uid")
+
+	tbs := testBuffer.String()
+	if !strings.HasSuffix(tbs, "}\n") || strings.Count(tbs, "\n") != 1 {
+		t.Fatalf("JSONFormat: log should be one json object per line, but get %s.", tbs)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(tbs), &got); err != nil {
+		t.Fatalf("JSONFormat: log should be valid json, but get %v.", err)
+	}
+
+	hopes := map[string]interface{}{
+		"level":      "warn",
+		"msg":        "Client Message Error: wrong uid",
+		"uid":        float64(7),
+		"err":        "Bad message.",
+		"rtt":        "20ms",
+		"fields.msg": "shadowed",
+		"type":       "chat",
+	}
+	for k, hope := range hopes {
+		if got[k] != hope {
+			t.Errorf("JSONFormat: %s is wrong, hope %v, get %v.", k, hope, got[k])
+		}
+	}
+	if caller, _ := got["caller"].(string); !strings.HasPrefix(caller, "encoder_test.go:") {
+		t.Errorf("JSONFormat: caller is wrong, hope encoder_test.go, get %s.", caller)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, got["time"].(string)); err != nil {
+		t.Errorf("JSONFormat: time is wrong, get %v.", err)
+	}
+}
+
+func TestLoggerErrorOutput(t *testing.T) {
+	var out, errOut bytes.Buffer
+	l := NewLogger(&out, &errOut, InfoLevel)
+
+	l.Infoln("testing_info")
+	l.Errorln("testing_error")
+	if !strings.Contains(out.String(), "testing_info") || strings.Contains(out.String(), "testing_error") {
+		t.Errorf("NewLogger: output is wrong, get %s.", out.String())
+	}
+	if !strings.Contains(errOut.String(), "testing_error") {
+		t.Errorf("NewLogger: error output is wrong, get %s.", errOut.String())
+	}
+}
@@ -2,6 +2,7 @@
 package log
 
 import (
+	"io"
 	"runtime"
 	"strings"
 	"time"
@@ -9,7 +10,8 @@ import (
 
 // levels
 const (
-	InfoLevel = iota
+	DebugLevel = iota
+	InfoLevel
 	WarnLevel
 	ErrorLevel
 	PanicLevel
@@ -20,15 +22,22 @@ const (
 	errorPrefix = "[Error]"
 	panicPrefix = "[Panic]"
 	fatalPrefix = "[Fatal]"
+	debugPrefix = "[Debug]"
 )
 
+// Fields is the structured context of logs, such as uid, room id and message type.
+type Fields map[string]interface{}
+
 // Logger defines the base interface of logger.
 // logger should be open while the program running, so it has no Close method.
 //
 // its base feature:
 //   * add log level
 //   * filter low level logs
+//   * carry fields by child loggers
 type Logger interface {
+	// Debugf print debug level log.
+	Debugf(format string, v ...interface{})
 	// Infof print info level log.
 	Infof(format string, v ...interface{})
 	// Warnf print warn level log.
@@ -39,6 +48,8 @@ type Logger interface {
 	Panicf(format string, v ...interface{})
 	// Fatal is equivalent to l.Print() followed by a call to os.Exit(1).
 	Fatalf(format string, v ...interface{})
+	// Debugln print debug level log.
+	Debugln(v ...interface{})
 	// Infoln print info level log.
 	Infoln(v ...interface{})
 	// Warnln print wran level log.
@@ -53,6 +64,25 @@ type Logger interface {
 	MinLevel() byte
 	// SetMinLevel set the minLevel of logger.
 	SetMinLevel(byte)
+	// SetFormat set the format of logs, TextFormat or JSONFormat.
+	SetFormat(Format)
+	// SetOutput replace the output and error output of logger.
+	SetOutput(out io.Writer, err io.Writer)
+	// EnableAsync make logger write logs in background with a bounded buffer.
+	EnableAsync(AsyncOptions)
+	// Flush wait until all buffered logs are written.
+	Flush()
+	// With create a child logger which prints fields with every log,
+	// the child shares outputs, min level and format with its parent.
+	With(fields Fields) Logger
+	// Named create a child logger whose min level can be changed alone.
+	Named(name string) Logger
+	// SetLevel set the min level of the named logger, "" is the root logger.
+	SetLevel(name string, level byte) error
+	// Levels return min levels of root logger("") and all named loggers.
+	Levels() map[string]byte
+	// SetVerbose print logs of all levels carrying field key=value for d.
+	SetVerbose(key string, value interface{}, d time.Duration)
 }
 
 // getInvokerLocation get filename and line according to skipNumber.
@@ -92,16 +122,12 @@ func itoa(buf *[]byte, i int, wid int) {
 
 // generateLogHead create log Head.
 // its formate is " date clock filePosition - levelPrefix "
-//
-// pos (position) is the relative position where calling log method relatives to
-// generateLogHead in function invocation stack.
-func generateLogHead(buf *[]byte, pos uint, levelPrefix string) {
+func generateLogHead(buf *[]byte, t time.Time, filename string, line int, levelPrefix string) {
 	*buf = (*buf)[:0]
 
 	*buf = append(*buf, ' ')
 
 	// timestamp
-	t := time.Now()
 	year, month, day := t.Date()
 	itoa(buf, year, 4)
 	*buf = append(*buf, '/')
@@ -119,7 +145,6 @@ func generateLogHead(buf *[]byte, pos uint, levelPrefix string) {
 	*buf = append(*buf, ' ')
 
 	// filename and line
-	filename, line := getInvokerLocation(int(pos) + 2)
 	*buf = append(*buf, filename...)
 	*buf = append(*buf, ':')
 	itoa(buf, line, -1)
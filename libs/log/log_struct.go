@@ -11,21 +11,50 @@ import (
 )
 
 var (
+	coloredDebug = color.Dye(color.FgCyan, debugPrefix)
 	coloredInfo  = color.Dye(color.FgGreen, infoPrefix)
 	coloredWarn  = color.Dye(color.FgYellow, warnPrefix)
 	coloredError = color.Dye(color.FgRed, errorPrefix)
 	coloredPanic = color.Dye(color.FgHiRed, panicPrefix)
 	coloredFatal = color.Dye(color.BgRed, fatalPrefix)
+
+	coloredPrefixes = [...]string{
+		DebugLevel: coloredDebug,
+		InfoLevel:  coloredInfo,
+		WarnLevel:  coloredWarn,
+		ErrorLevel: coloredError,
+		PanicLevel: coloredPanic,
+		FatalLevel: coloredFatal,
+	}
 )
 
 // TwoOutputLogger has two output, one is used to normal output and the other is used to error output,
 // it is goroutine safe.
+//
+// loggers created by With and Named share the sink of their parent.
 type TwoOutputLogger struct {
+	*sink
+	name   string // "" is the root logger
+	fields Fields
+}
+
+// sink is the shared part of a logger and its children.
+type sink struct {
 	minLevel byte
+	format   Format
 	m        sync.RWMutex
 	buf      []byte // for accumulating text to write
 	out      io.Writer
 	err      io.Writer
+
+	// async is nil until EnableAsync is called.
+	async *asyncWriter
+
+	// names are all named loggers, levels override minLevel for named loggers.
+	names  map[string]bool
+	levels map[string]byte
+	// verbose prints logs of all levels carrying the field until the time, keys are "key=value".
+	verbose map[string]time.Time
 }
 
 // NewStdLogger create and return a new TwoOutputLogger which implements Logger,
@@ -37,11 +66,11 @@ func NewStdLogger(level byte) *TwoOutputLogger {
 	if level > FatalLevel {
 		panic("Your min level of logger is too high!")
 	}
-	return &TwoOutputLogger{
+	return &TwoOutputLogger{sink: &sink{
 		minLevel: level,
 		out:      os.Stdout,
 		err:      os.Stderr,
-	}
+	}}
 }
 
 // NewSimpleFileLogger  create and return a new TwoOutputLogger which implements Logger and
@@ -69,11 +98,11 @@ func NewSimpleFileLogger(dirname string, prefix string, level byte) (*TwoOutputL
 		panic(err)
 	}
 
-	return &TwoOutputLogger{
+	return &TwoOutputLogger{sink: &sink{
 		minLevel: level,
 		out:      w,
 		err:      w,
-	}, w
+	}}, w
 }
 
 // NewSimpleLogger create and return a new TwoOutputLogger which implements Logger,
@@ -86,11 +115,11 @@ func NewSimpleLogger(w io.Writer, level byte) *TwoOutputLogger {
 		panic("Your w io.Writer should not be nil.")
 	}
 
-	return &TwoOutputLogger{
+	return &TwoOutputLogger{sink: &sink{
 		minLevel: level,
 		out:      w,
 		err:      w,
-	}
+	}}
 }
 
 // NewLogger create and return a new TwoOutputLogger which implements Logger.
@@ -105,230 +134,338 @@ func NewLogger(out io.Writer, err io.Writer, level byte) *TwoOutputLogger {
 	if err == nil {
 		panic("Your error output io.Writer should not be nil.")
 	}
-	return &TwoOutputLogger{
+	return &TwoOutputLogger{sink: &sink{
 		minLevel: level,
 		out:      out,
 		err:      err,
-	}
+	}}
 }
 
-// levelCheck check print whether is bigger than minLevel, if it is true return ture
+// levelCheck check print whether is bigger than minLevel, if it is true return ture,
+// it must be called with lock.
 func (l *TwoOutputLogger) levelCheck(printLevel byte) bool {
-	if l.minLevel <= printLevel {
+	if l.level() <= printLevel {
 		return true
 	}
 
-	return false
+	return l.isVerbose()
 }
 
-// Infof print info level log.
-func (l *TwoOutputLogger) Infof(format string, v ...interface{}) {
-	s := fmt.Sprintf(format, v...)
-
-	l.m.Lock()
-	defer l.m.Unlock()
-	if !l.levelCheck(InfoLevel) {
-		return
+// level return the min level of logger, a named logger uses the min level of root
+// if its level is not set. it must be called with lock.
+func (l *TwoOutputLogger) level() byte {
+	if level, ok := l.levels[l.name]; ok {
+		return level
 	}
+	return l.minLevel
+}
 
-	generateLogHead(&l.buf, 1, coloredInfo)
-	l.buf = append(l.buf, s...)
+// verboseKey ...
+func verboseKey(key string, value interface{}) string {
+	return key + "=" + fmt.Sprint(value)
+}
 
-	_, err := l.out.Write(l.buf)
-	if err != nil {
-		panic(err)
+// isVerbose check whether logger carries a verbose field, it must be called with lock.
+func (l *TwoOutputLogger) isVerbose() bool {
+	if len(l.verbose) == 0 || len(l.fields) == 0 {
+		return false
 	}
-}
 
-// Infoln print info level log.
-func (l *TwoOutputLogger) Infoln(v ...interface{}) {
-	s := fmt.Sprintln(v...)
+	now := time.Now()
+	for k, v := range l.fields {
+		if until, ok := l.verbose[verboseKey(k, v)]; ok && now.Before(until) {
+			return true
+		}
+	}
+	return false
+}
 
+// output encode s with fields of logger and write it, error and higher level logs are
+// written to error output. it returns false if the log is filtered by level.
+//
+// in async mode, logs are filtered by callsite and pushed into buffer instead, panic and fatal
+// logs are never filtered and flushed immediately.
+func (l *TwoOutputLogger) output(level byte, s string) bool {
 	l.m.Lock()
-	defer l.m.Unlock()
-	if !l.levelCheck(InfoLevel) {
-		return
+	if !l.levelCheck(level) {
+		l.m.Unlock()
+		return false
+	}
+
+	// 0: getInvokerLocation, 1: output, 2: log method, 3: invoker
+	filename, line := getInvokerLocation(3)
+	e := &entry{
+		time:   time.Now(),
+		file:   filename,
+		line:   line,
+		level:  level,
+		name:   l.name,
+		msg:    s,
+		fields: l.fields,
+	}
+
+	if l.async == nil {
+		defer l.m.Unlock()
+
+		_, err := l.encode(e).Write(l.buf)
+		if err != nil {
+			panic(err)
+		}
+		return true
 	}
 
-	generateLogHead(&l.buf, 1, coloredInfo)
-	l.buf = append(l.buf, s...)
+	a := l.async
+	critical := level >= PanicLevel
+	entries := []*entry{e}
+	if !critical {
+		entries = a.filter(e)
+	}
+	records := l.records(entries)
+	l.m.Unlock()
 
-	_, err := l.out.Write(l.buf)
-	if err != nil {
-		panic(err)
+	for _, r := range records {
+		a.push(r, critical)
+	}
+	if critical {
+		a.flush()
 	}
+	return true
 }
 
-// Fatalf print fatal level log.
-func (l *TwoOutputLogger) Fatalf(format string, v ...interface{}) {
-	s := fmt.Sprintf(format, v...)
+// encode encode e into buf according to format and return the output it should be written to,
+// it must be called with lock.
+func (s *sink) encode(e *entry) io.Writer {
+	switch s.format {
+	case JSONFormat:
+		encodeJSON(&s.buf, e)
+	default:
+		encodeText(&s.buf, e)
+	}
 
-	l.m.Lock()
-	defer l.m.Unlock()
-	if !l.levelCheck(FatalLevel) {
-		return
+	if e.level >= ErrorLevel {
+		return s.err
 	}
+	return s.out
+}
 
-	generateLogHead(&l.buf, 1, coloredFatal)
-	l.buf = append(l.buf, s...)
+// Debugf print debug level log.
+func (l *TwoOutputLogger) Debugf(format string, v ...interface{}) {
+	l.output(DebugLevel, fmt.Sprintf(format, v...))
+}
 
-	_, err := l.out.Write(l.buf)
-	if err != nil {
-		panic(err)
-	}
-	os.Exit(1)
+// Debugln print debug level log.
+func (l *TwoOutputLogger) Debugln(v ...interface{}) {
+	l.output(DebugLevel, fmt.Sprintln(v...))
 }
 
-// Fatalln print fatal level log.
-func (l *TwoOutputLogger) Fatalln(v ...interface{}) {
-	s := fmt.Sprintln(v...)
+// Infof print info level log.
+func (l *TwoOutputLogger) Infof(format string, v ...interface{}) {
+	l.output(InfoLevel, fmt.Sprintf(format, v...))
+}
 
-	l.m.Lock()
-	defer l.m.Unlock()
-	if !l.levelCheck(FatalLevel) {
-		return
-	}
+// Infoln print info level log.
+func (l *TwoOutputLogger) Infoln(v ...interface{}) {
+	l.output(InfoLevel, fmt.Sprintln(v...))
+}
 
-	generateLogHead(&l.buf, 1, coloredFatal)
-	l.buf = append(l.buf, s...)
+// Fatalf print fatal level log.
+func (l *TwoOutputLogger) Fatalf(format string, v ...interface{}) {
+	if l.output(FatalLevel, fmt.Sprintf(format, v...)) {
+		os.Exit(1)
+	}
+}
 
-	_, err := l.out.Write(l.buf)
-	if err != nil {
-		panic(err)
+// Fatalln print fatal level log.
+func (l *TwoOutputLogger) Fatalln(v ...interface{}) {
+	if l.output(FatalLevel, fmt.Sprintln(v...)) {
+		os.Exit(1)
 	}
-	os.Exit(1)
 }
 
 // Panicf print panic level log.
 func (l *TwoOutputLogger) Panicf(format string, v ...interface{}) {
 	s := fmt.Sprintf(format, v...)
-
-	l.m.Lock()
-	defer l.m.Unlock()
-	if !l.levelCheck(PanicLevel) {
-		return
-	}
-
-	generateLogHead(&l.buf, 1, coloredPanic)
-	l.buf = append(l.buf, s...)
-
-	_, err := l.out.Write(l.buf)
-	if err != nil {
-		panic(err)
+	if l.output(PanicLevel, s) {
+		panic(s)
 	}
-	panic(s)
 }
 
 // Panicln print panic level log.
 func (l *TwoOutputLogger) Panicln(v ...interface{}) {
 	s := fmt.Sprintln(v...)
-
-	l.m.Lock()
-	defer l.m.Unlock()
-	if !l.levelCheck(PanicLevel) {
-		return
-	}
-
-	generateLogHead(&l.buf, 1, coloredPanic)
-	l.buf = append(l.buf, s...)
-
-	_, err := l.out.Write(l.buf)
-	if err != nil {
-		panic(err)
+	if l.output(PanicLevel, s) {
+		panic(s)
 	}
-	panic(s)
 }
 
 // Errorf print error level log.
 func (l *TwoOutputLogger) Errorf(format string, v ...interface{}) {
-	s := fmt.Sprintf(format, v...)
+	l.output(ErrorLevel, fmt.Sprintf(format, v...))
+}
 
-	l.m.Lock()
-	defer l.m.Unlock()
-	if !l.levelCheck(ErrorLevel) {
-		return
-	}
+// Errorln print error level log.
+func (l *TwoOutputLogger) Errorln(v ...interface{}) {
+	l.output(ErrorLevel, fmt.Sprintln(v...))
+}
 
-	generateLogHead(&l.buf, 1, coloredError)
-	l.buf = append(l.buf, s...)
+// Warnf print warn level log.
+func (l *TwoOutputLogger) Warnf(format string, v ...interface{}) {
+	l.output(WarnLevel, fmt.Sprintf(format, v...))
+}
 
-	_, err := l.out.Write(l.buf)
-	if err != nil {
-		panic(err)
-	}
+// Warnln print warn level log.
+func (l *TwoOutputLogger) Warnln(v ...interface{}) {
+	l.output(WarnLevel, fmt.Sprintln(v...))
 }
 
-// Errorln print error level log.
-func (l *TwoOutputLogger) Errorln(v ...interface{}) {
-	s := fmt.Sprintln(v...)
+// MinLevel return the minimize level logger should print.
+func (l *TwoOutputLogger) MinLevel() byte {
+	l.m.RLock()
+	defer l.m.RUnlock()
 
+	return l.level()
+}
+
+// SetMinLevel set the minLevel of logger, the level of a named logger only changes itself,
+// the level of root logger changes named loggers whose level is not set.
+func (l *TwoOutputLogger) SetMinLevel(level byte) {
 	l.m.Lock()
 	defer l.m.Unlock()
-	if !l.levelCheck(ErrorLevel) {
+
+	if level > FatalLevel {
+		return
+	}
+	if l.name == "" {
+		l.minLevel = level
 		return
 	}
+	if l.levels == nil {
+		l.levels = make(map[string]byte)
+	}
+	l.levels[l.name] = level
+}
 
-	generateLogHead(&l.buf, 1, coloredError)
-	l.buf = append(l.buf, s...)
+// Named create a child logger with its own min level, loggers with the same name share
+// the level, which can be changed by SetLevel at runtime.
+func (l *TwoOutputLogger) Named(name string) Logger {
+	l.m.Lock()
+	defer l.m.Unlock()
 
-	_, err := l.out.Write(l.buf)
-	if err != nil {
-		panic(err)
+	if l.names == nil {
+		l.names = make(map[string]bool)
+	}
+	l.names[name] = true
+
+	return &TwoOutputLogger{
+		sink:   l.sink,
+		name:   name,
+		fields: l.fields,
 	}
 }
 
-// Warnf print warn level log.
-func (l *TwoOutputLogger) Warnf(format string, v ...interface{}) {
-	s := fmt.Sprintf(format, v...)
+// SetLevel set the min level of the named logger, "" is the root logger.
+func (l *TwoOutputLogger) SetLevel(name string, level byte) error {
+	if level > FatalLevel {
+		return errInvalidLevel
+	}
 
 	l.m.Lock()
 	defer l.m.Unlock()
-	if !l.levelCheck(WarnLevel) {
-		return
+
+	if name == "" {
+		l.minLevel = level
+		return nil
+	}
+	if !l.names[name] {
+		return fmt.Errorf("Logger %s is not exist.", name)
 	}
+	if l.levels == nil {
+		l.levels = make(map[string]byte)
+	}
+	l.levels[name] = level
+	return nil
+}
 
-	generateLogHead(&l.buf, 1, coloredWarn)
-	l.buf = append(l.buf, s...)
+// Levels return min levels of root logger("") and all named loggers.
+func (l *TwoOutputLogger) Levels() map[string]byte {
+	l.m.RLock()
+	defer l.m.RUnlock()
 
-	_, err := l.out.Write(l.buf)
-	if err != nil {
-		panic(err)
+	levels := make(map[string]byte, len(l.names)+1)
+	levels[""] = l.minLevel
+	for name := range l.names {
+		if level, ok := l.levels[name]; ok {
+			levels[name] = level
+		} else {
+			levels[name] = l.minLevel
+		}
 	}
+	return levels
 }
 
-// Warnln print warn level log.
-func (l *TwoOutputLogger) Warnln(v ...interface{}) {
-	s := fmt.Sprintln(v...)
-
+// SetVerbose print logs of all levels carrying field key=value for d, such as
+// all logs of a user. d <= 0 stops it.
+func (l *TwoOutputLogger) SetVerbose(key string, value interface{}, d time.Duration) {
 	l.m.Lock()
 	defer l.m.Unlock()
-	if !l.levelCheck(WarnLevel) {
-		return
+
+	if l.verbose == nil {
+		l.verbose = make(map[string]time.Time)
 	}
 
-	generateLogHead(&l.buf, 1, coloredWarn)
-	l.buf = append(l.buf, s...)
+	now := time.Now()
+	for k, until := range l.verbose {
+		if !now.Before(until) {
+			delete(l.verbose, k)
+		}
+	}
 
-	_, err := l.out.Write(l.buf)
-	if err != nil {
-		panic(err)
+	k := verboseKey(key, value)
+	if d <= 0 {
+		delete(l.verbose, k)
+		return
 	}
+	l.verbose[k] = now.Add(d)
 }
 
-// MinLevel return the minimize level logger should print.
-func (l *TwoOutputLogger) MinLevel() byte {
-	l.m.RLock()
-	defer l.m.RUnlock()
+// SetFormat set the format of logs, it also changes the format of children and parent.
+func (l *TwoOutputLogger) SetFormat(f Format) {
+	l.m.Lock()
+	defer l.m.Unlock()
 
-	return l.minLevel
+	if f <= JSONFormat {
+		l.format = f
+	}
 }
 
-// SetMinLevel set the minLevel of logger.
-func (l *TwoOutputLogger) SetMinLevel(level byte) {
+// SetOutput replace the output and error output of logger, it also changes
+// the outputs of children and parent. nil writer is ignored.
+func (l *TwoOutputLogger) SetOutput(out io.Writer, err io.Writer) {
 	l.m.Lock()
 	defer l.m.Unlock()
 
-	if level <= FatalLevel {
-		l.minLevel = level
+	if out != nil {
+		l.out = out
+	}
+	if err != nil {
+		l.err = err
+	}
+}
+
+// With create a child logger which prints fields with every log,
+// fields of child override the same keys of parent.
+func (l *TwoOutputLogger) With(fields Fields) Logger {
+	merged := make(Fields, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	return &TwoOutputLogger{
+		sink:   l.sink,
+		name:   l.name,
+		fields: merged,
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLogColor(t *testing.T) {
@@ -112,3 +113,70 @@ func BenchmarkDefaultLogger(b *testing.B) {
 		logger.Println("log.Logger test ", "testing")
 	}
 }
+
+func TestLoggerNamed(t *testing.T) {
+	var testBuffer bytes.Buffer
+	l := NewSimpleLogger(&testBuffer, InfoLevel)
+	room := l.Named("room")
+	user := l.Named("user")
+
+	if err := l.SetLevel("room", DebugLevel); err != nil {
+		t.Fatal(err)
+	}
+	room.Debugln("testing_room")
+	user.Debugln("testing_user")
+	if tbs := testBuffer.String(); !strings.Contains(tbs, "testing_room") || strings.Contains(tbs, "testing_user") {
+		t.Errorf("Named: only room logger should print debug log, but get %s.", tbs)
+	}
+
+	// user logger follows root logger.
+	l.SetMinLevel(ErrorLevel)
+	if level := user.MinLevel(); level != ErrorLevel {
+		t.Errorf("Named: level of user logger is wrong, hope %d, get %d.", ErrorLevel, level)
+	}
+	if level := room.With(Fields{"rid": 1}).MinLevel(); level != DebugLevel {
+		t.Errorf("Named: level of room logger is wrong, hope %d, get %d.", DebugLevel, level)
+	}
+
+	levels := l.Levels()
+	hopes := map[string]byte{"": ErrorLevel, "room": DebugLevel, "user": ErrorLevel}
+	for name, hope := range hopes {
+		if levels[name] != hope {
+			t.Errorf("Levels: level of %s is wrong, hope %d, get %d.", name, hope, levels[name])
+		}
+	}
+
+	if err := l.SetLevel("unknown", InfoLevel); err == nil {
+		t.Error("SetLevel: unknown logger should fail.")
+	}
+}
+
+func TestLoggerSetVerbose(t *testing.T) {
+	var testBuffer bytes.Buffer
+	l := NewSimpleLogger(&testBuffer, WarnLevel).Named("user")
+
+	l.SetVerbose("uid", 3, time.Minute)
+	l.With(Fields{"uid": 3}).Debugln("testing_uid3")
+	l.With(Fields{"uid": 4}).Debugln("testing_uid4")
+	if tbs := testBuffer.String(); !strings.Contains(tbs, "testing_uid3") || strings.Contains(tbs, "testing_uid4") {
+		t.Errorf("SetVerbose: only logs of uid 3 should be printed, but get %s.", tbs)
+	}
+
+	testBuffer.Reset()
+	l.SetVerbose("uid", 3, 0)
+	l.With(Fields{"uid": 3}).Debugln("testing_uid3")
+	if testBuffer.Len() != 0 {
+		t.Errorf("SetVerbose: verbose should be stopped, but get %s.", testBuffer.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for level := byte(DebugLevel); level <= FatalLevel; level++ {
+		if l, err := ParseLevel(strings.ToUpper(LevelName(level))); err != nil || l != level {
+			t.Errorf("ParseLevel: level is wrong, hope %d, get %d, %v.", level, l, err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel: unknown level should fail.")
+	}
+}
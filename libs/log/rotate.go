@@ -0,0 +1,344 @@
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	logSuffix    = ".log"
+	gzipSuffix   = ".gz"
+	backupLayout = "20060102_150405.000"
+
+	// rotateRetryDuration is the duration before rolling again after a failure.
+	rotateRetryDuration = time.Minute
+)
+
+var errWriterClosed = errors.New("Rotating writer is closed.")
+
+// RotateConfig configures a RotatingWriter.
+type RotateConfig struct {
+	// Dir is the directory of log files.
+	Dir string
+	// Prefix is the name of log files, current logs are written into 'Dir/Prefix.log'.
+	Prefix string
+	// MaxSize is the max bytes of a log file before rolling, 0 means no limit.
+	MaxSize int64
+	// Daily rolls the log file when the day changes.
+	Daily bool
+	// MaxBackups is the number of old log files kept, 0 keeps all.
+	MaxBackups int
+	// Compress gzips old log files.
+	Compress bool
+}
+
+// RotatingWriter is an io.Writer writing into a file, it rolls the file by size or day.
+// old files are renamed as 'Prefix_date_clock.log', it is goroutine safe.
+type RotatingWriter struct {
+	cfg RotateConfig
+
+	m      sync.Mutex
+	file   *os.File
+	size   int64
+	day    int // year*10000 + month*100 + day of the opened file
+	closed bool
+
+	// retryAt delays the next rotation after a failed one.
+	retryAt time.Time
+
+	// now is replaced in testing.
+	now func() time.Time
+
+	// mill compresses and removes old files in background, one at a time.
+	millM sync.Mutex
+	mill  sync.WaitGroup
+}
+
+// NewRotatingWriter open 'Dir/Prefix.log' in append mode and return a RotatingWriter.
+func NewRotatingWriter(cfg RotateConfig) (*RotatingWriter, error) {
+	if cfg.Dir == "" || cfg.Prefix == "" {
+		return nil, errors.New("Dir and Prefix of rotating writer should not be \"\".")
+	}
+	if err := os.MkdirAll(cfg.Dir, 0700); err != nil {
+		return nil, err
+	}
+
+	w := &RotatingWriter{
+		cfg: cfg,
+		now: time.Now,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// NewRotatingFileLogger create and return a new TwoOutputLogger which writes into a RotatingWriter,
+// the writer is returned for closing.
+func NewRotatingFileLogger(cfg RotateConfig, level byte) (*TwoOutputLogger, *RotatingWriter) {
+	w, err := NewRotatingWriter(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	return NewSimpleLogger(w, level), w
+}
+
+// dayOf ...
+func dayOf(t time.Time) int {
+	year, month, day := t.Date()
+	return year*10000 + int(month)*100 + day
+}
+
+// endOfDay return the last millisecond of day in loc.
+func endOfDay(day int, loc *time.Location) time.Time {
+	return time.Date(day/10000, time.Month(day/100%100), day%100, 23, 59, 59, int(time.Millisecond)*999, loc)
+}
+
+// filename return the path of current log file.
+func (w *RotatingWriter) filename() string {
+	return path.Join(w.cfg.Dir, w.cfg.Prefix+logSuffix)
+}
+
+// open open current log file, it must be called with lock.
+func (w *RotatingWriter) open() error {
+	f, err := os.OpenFile(w.filename(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.file = f
+	w.size = info.Size()
+	w.day = dayOf(w.now())
+	return nil
+}
+
+// Write write p into current log file, the file is rolled before writing if
+// it is too large or opened in another day.
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if w.closed {
+		return 0, errWriterClosed
+	}
+
+	if w.shouldRotate(len(p)) {
+		if err := w.rotate(); err != nil {
+			// keep writing into the opened file, and try again later.
+			w.retryAt = w.now().Add(rotateRetryDuration)
+			os.Stderr.WriteString("Failed to rotate log file: " + err.Error() + "\n")
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// shouldRotate ...
+func (w *RotatingWriter) shouldRotate(n int) bool {
+	if w.now().Before(w.retryAt) {
+		return false
+	}
+	if w.cfg.MaxSize > 0 && w.size > 0 && w.size+int64(n) > w.cfg.MaxSize {
+		return true
+	}
+	if w.cfg.Daily && dayOf(w.now()) != w.day {
+		return true
+	}
+	return false
+}
+
+// Rotate roll current log file immediately.
+func (w *RotatingWriter) Rotate() error {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if w.closed {
+		return errWriterClosed
+	}
+	return w.rotate()
+}
+
+// rotate rename current log file as a backup and open a new one, it must be called with lock.
+// The opened file is kept if any step fails, so logs are never written into a closed file.
+func (w *RotatingWriter) rotate() error {
+	// backup is named by the day of the opened file when rolling daily.
+	stamp := w.now()
+	if dayOf(stamp) != w.day {
+		stamp = endOfDay(w.day, stamp.Location())
+	}
+	backup := w.backupName(stamp)
+
+	// a file moved by other programs is not renamed again, just open a new one.
+	renamed := true
+	if err := os.Rename(w.filename(), backup); err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		renamed = false
+	}
+
+	old := w.file
+	if err := w.open(); err != nil {
+		return err
+	}
+	old.Close()
+
+	if renamed {
+		w.mill.Add(1)
+		go w.millBackups(backup)
+	}
+	return nil
+}
+
+// backupName return an unused name of backup rolled at stamp, a sequence such as '_001'
+// is appended if backups are rolled at the same stamp, so none is overwritten.
+func (w *RotatingWriter) backupName(stamp time.Time) string {
+	base := path.Join(w.cfg.Dir, w.cfg.Prefix+"_"+stamp.Format(backupLayout))
+	name := base + logSuffix
+	for seq := 1; exists(name) || exists(name+gzipSuffix); seq++ {
+		name = fmt.Sprintf("%s_%03d%s", base, seq, logSuffix)
+	}
+	return name
+}
+
+// exists ...
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// Reopen open current log file again, it is used after the file is moved by
+// other programs such as logrotate. The old file is kept if it fails.
+func (w *RotatingWriter) Reopen() error {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if w.closed {
+		return errWriterClosed
+	}
+
+	old := w.file
+	if err := w.open(); err != nil {
+		return err
+	}
+	old.Close()
+	return nil
+}
+
+// Close close current log file and wait for compressing old files.
+func (w *RotatingWriter) Close() error {
+	w.m.Lock()
+	if w.closed {
+		w.m.Unlock()
+		return nil
+	}
+	w.closed = true
+	err := w.file.Close()
+	w.m.Unlock()
+
+	w.mill.Wait()
+	return err
+}
+
+// millBackups compress the new backup and remove backups exceeding MaxBackups.
+func (w *RotatingWriter) millBackups(backup string) {
+	defer w.mill.Done()
+
+	w.millM.Lock()
+	defer w.millM.Unlock()
+
+	if w.cfg.Compress {
+		if err := compressFile(backup); err != nil {
+			os.Stderr.WriteString("Failed to compress log file " + backup + ": " + err.Error() + "\n")
+		}
+	}
+
+	if w.cfg.MaxBackups <= 0 {
+		return
+	}
+	backups, err := w.backups()
+	if err != nil {
+		os.Stderr.WriteString("Failed to list log files: " + err.Error() + "\n")
+		return
+	}
+	for i := 0; i < len(backups)-w.cfg.MaxBackups; i++ {
+		os.Remove(path.Join(w.cfg.Dir, backups[i]))
+	}
+}
+
+// backups return names of old log files from oldest to newest.
+func (w *RotatingWriter) backups() ([]string, error) {
+	d, err := os.Open(w.cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	names, err := d.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := w.cfg.Prefix + "_"
+	backups := make([]string, 0, len(names))
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if strings.HasSuffix(name, logSuffix) || strings.HasSuffix(name, logSuffix+gzipSuffix) {
+			backups = append(backups, name)
+		}
+	}
+
+	// date and clock in names make them sorted by time.
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// compressFile gzip the file into 'filename.gz' and remove it.
+func compressFile(filename string) error {
+	src, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(filename+gzipSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(dst)
+	if _, err := io.Copy(gw, src); err != nil {
+		gw.Close()
+		dst.Close()
+		os.Remove(filename + gzipSuffix)
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		dst.Close()
+		os.Remove(filename + gzipSuffix)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(filename)
+}
@@ -0,0 +1,164 @@
+package log
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRotatingWriter(t *testing.T, cfg RotateConfig) (*RotatingWriter, *time.Time) {
+	dir, err := ioutil.TempDir("", "rotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Dir = dir
+	cfg.Prefix = "test"
+
+	w, err := NewRotatingWriter(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2017, 6, 1, 12, 0, 0, 0, time.Local)
+	w.now = func() time.Time { return now }
+	w.day = dayOf(now)
+	return w, &now
+}
+
+func TestRotatingWriterSize(t *testing.T) {
+	w, now := newTestRotatingWriter(t, RotateConfig{MaxSize: 10, MaxBackups: 2})
+	defer os.RemoveAll(w.cfg.Dir)
+
+	for i := 0; i < 4; i++ {
+		*now = now.Add(time.Second)
+		w.Write([]byte("123456789\n"))
+	}
+	w.Close()
+
+	backups, _ := w.backups()
+	if len(backups) != 2 {
+		t.Fatalf("Number of backups is wrong, hope %d, get %d.", 2, len(backups))
+	}
+	// the oldest one is removed.
+	if hope := "test_20170601_120003.000.log"; backups[0] != hope {
+		t.Errorf("Oldest backup is wrong, hope %s, get %s.", hope, backups[0])
+	}
+
+	bs, _ := ioutil.ReadFile(w.filename())
+	if string(bs) != "123456789\n" {
+		t.Errorf("Current log file is wrong, get %q.", bs)
+	}
+}
+
+func TestRotatingWriterDaily(t *testing.T) {
+	w, now := newTestRotatingWriter(t, RotateConfig{Daily: true, Compress: true})
+	defer os.RemoveAll(w.cfg.Dir)
+
+	w.Write([]byte("day one\n"))
+	w.Write([]byte("day one again\n"))
+	*now = now.Add(time.Hour * 24)
+	w.Write([]byte("day two\n"))
+	w.Close()
+
+	backups, _ := w.backups()
+	if len(backups) != 1 || !strings.HasSuffix(backups[0], gzipSuffix) {
+		t.Fatalf("Backups are wrong, hope one gzip file, get %v.", backups)
+	}
+	// backup is named by the day it was written.
+	if hope := "test_20170601_235959.999.log.gz"; backups[0] != hope {
+		t.Errorf("Name of backup is wrong, hope %s, get %s.", hope, backups[0])
+	}
+
+	f, err := os.Open(path.Join(w.cfg.Dir, backups[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs, _ := ioutil.ReadAll(gr)
+	if hope := "day one\nday one again\n"; string(bs) != hope {
+		t.Errorf("Compressed log file is wrong, hope %q, get %q.", hope, bs)
+	}
+}
+
+func TestRotatingWriterReopen(t *testing.T) {
+	w, _ := newTestRotatingWriter(t, RotateConfig{})
+	defer os.RemoveAll(w.cfg.Dir)
+
+	w.Write([]byte("before\n"))
+	moved := w.filename() + ".1"
+	os.Rename(w.filename(), moved)
+
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("after\n"))
+	w.Close()
+
+	if bs, _ := ioutil.ReadFile(moved); string(bs) != "before\n" {
+		t.Errorf("Moved log file is wrong, get %q.", bs)
+	}
+	if bs, _ := ioutil.ReadFile(w.filename()); string(bs) != "after\n" {
+		t.Errorf("Reopened log file is wrong, get %q.", bs)
+	}
+
+	if _, err := w.Write([]byte("closed\n")); err != errWriterClosed {
+		t.Errorf("Write after closing should fail, get %v.", err)
+	}
+}
+
+func TestRotatingWriterRotateFailed(t *testing.T) {
+	w, now := newTestRotatingWriter(t, RotateConfig{MaxSize: 10})
+	defer os.RemoveAll(w.cfg.Dir)
+
+	w.Write([]byte("123456789\n"))
+	// neither renaming nor opening works without the directory.
+	os.RemoveAll(w.cfg.Dir)
+	if n, err := w.Write([]byte("failed\n")); err != nil || n != 7 {
+		t.Fatalf("Write should go on when rotating fails, get %d, %v.", n, err)
+	}
+
+	// rotating is not retried at once.
+	os.MkdirAll(w.cfg.Dir, 0700)
+	w.Write([]byte("again\n"))
+	if _, err := os.Stat(w.filename()); !os.IsNotExist(err) {
+		t.Errorf("Log file should not be opened before retrying, get %v.", err)
+	}
+
+	*now = now.Add(rotateRetryDuration)
+	w.Write([]byte("retried\n"))
+	if bs, _ := ioutil.ReadFile(w.filename()); string(bs) != "retried\n" {
+		t.Errorf("Log file is wrong after retrying, get %q.", bs)
+	}
+
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("Close should not fail, get %v.", err)
+	}
+}
+
+func TestRotatingWriterSameStamp(t *testing.T) {
+	w, _ := newTestRotatingWriter(t, RotateConfig{MaxSize: 10})
+	defer os.RemoveAll(w.cfg.Dir)
+
+	// a burst of logs rolls the file several times at the same clock.
+	for i := 0; i < 3; i++ {
+		w.Write([]byte("123456789\n"))
+	}
+	w.Close()
+
+	backups, _ := w.backups()
+	hope := []string{"test_20170601_120000.000.log", "test_20170601_120000.000_001.log"}
+	if strings.Join(backups, ",") != strings.Join(hope, ",") {
+		t.Errorf("Backups are wrong, hope %v, get %v.", hope, backups)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build !windows
+
+package log
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ReopenOnSignal reopen current log file when the process receives SIGUSR1,
+// the returned function stops listening.
+func (w *RotatingWriter) ReopenOnSignal() (stop func()) {
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, syscall.SIGUSR1)
+
+	go func() {
+		for {
+			select {
+			case <-c:
+				if err := w.Reopen(); err != nil {
+					os.Stderr.WriteString("Failed to reopen log file: " + err.Error() + "\n")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
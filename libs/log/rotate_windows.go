@@ -0,0 +1,8 @@
+//go:build windows
+
+package log
+
+// ReopenOnSignal does nothing on windows, which has no SIGUSR1.
+func (w *RotatingWriter) ReopenOnSignal() (stop func()) {
+	return func() {}
+}
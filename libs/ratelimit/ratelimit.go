@@ -0,0 +1,58 @@
+// Package ratelimit provide a simple token bucket to limit the rate of events.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket, tokens are added into bucket at rate per second until
+// the number of tokens reaches burst, every allowed event takes one token.
+// it is goroutine safe.
+type Bucket struct {
+	m      sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket create a full Bucket which allows rate events per second and burst events
+// at most.
+func NewBucket(rate float64, burst int) *Bucket {
+	if rate <= 0 || burst <= 0 {
+		panic("Rate and burst of bucket should be bigger than 0!")
+	}
+
+	return &Bucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow report whether an event may happen now, if it is true, a token is taken.
+func (bk *Bucket) Allow() bool {
+	return bk.allowAt(time.Now())
+}
+
+// allowAt report whether an event may happen at now.
+func (bk *Bucket) allowAt(now time.Time) bool {
+	bk.m.Lock()
+	defer bk.m.Unlock()
+
+	if elapsed := now.Sub(bk.last); elapsed > 0 {
+		bk.tokens += elapsed.Seconds() * bk.rate
+		if bk.tokens > bk.burst {
+			bk.tokens = bk.burst
+		}
+	}
+	bk.last = now
+
+	if bk.tokens < 1 {
+		return false
+	}
+	bk.tokens--
+	return true
+}
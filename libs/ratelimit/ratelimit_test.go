@@ -0,0 +1,41 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBucket ...
+func TestBucket(t *testing.T) {
+	bk := NewBucket(2, 3)
+	now := bk.last
+
+	// burst
+	for i := 0; i < 3; i++ {
+		if !bk.allowAt(now) {
+			t.Errorf("The %dth event should be allowed.", i+1)
+		}
+	}
+	if bk.allowAt(now) {
+		t.Error("Events more than burst should not be allowed.")
+	}
+
+	// 2 tokens per second
+	now = now.Add(500 * time.Millisecond)
+	if !bk.allowAt(now) {
+		t.Error("Event should be allowed after 500ms.")
+	}
+	if bk.allowAt(now) {
+		t.Error("Only one token should be added after 500ms.")
+	}
+
+	// tokens won't be more than burst
+	now = now.Add(time.Hour)
+	count := 0
+	for bk.allowAt(now) {
+		count++
+	}
+	if count != 3 {
+		t.Errorf("Number of allowed events is wrong, hope %d, get %d.", 3, count)
+	}
+}
@@ -1,13 +1,27 @@
 package main
 
 import (
+	"barrage-server/admin"
 	b "barrage-server/base"
+	"barrage-server/console"
+	"barrage-server/libs/log"
 	r "barrage-server/room"
 	"barrage-server/socket"
+	"barrage-server/store"
 	"flag"
 )
 
 var env string
+var adminAddr string
+var consolePath string
+var statsPath string
+var logFormat string
+var debug bool
+var logDir string
+var logMaxSize int64
+var logKeep int
+var logCompress bool
+var logSync bool
 
 // TODO: write config package.
 func init() {
@@ -17,6 +31,17 @@ func init() {
 	)
 	flag.StringVar(&env, "env", defaultEnv, usage)
 	flag.StringVar(&env, "e", defaultEnv, usage+" (shorthand)")
+
+	flag.StringVar(&adminAddr, "admin", "127.0.0.1:2335", "set the address of admin api")
+	flag.StringVar(&consolePath, "console", "console.sock", "set the unix socket of admin console, empty disables it")
+	flag.StringVar(&statsPath, "stats", "stats.json", "set the file to store player stats")
+	flag.StringVar(&logFormat, "log-format", "text", "set the format of logs[text, json]")
+	flag.BoolVar(&debug, "debug", false, "print debug level logs")
+	flag.StringVar(&logDir, "log-dir", "", "write logs into rotating files in the directory instead of stdout")
+	flag.Int64Var(&logMaxSize, "log-max-size", 100, "set the max megabytes of a log file, 0 means no limit")
+	flag.IntVar(&logKeep, "log-keep", 7, "set the number of old log files kept, 0 keeps all")
+	flag.BoolVar(&logCompress, "log-compress", true, "gzip old log files")
+	flag.BoolVar(&logSync, "log-sync", false, "write logs synchronously without dropping or deduplicating")
 }
 
 func main() {
@@ -34,11 +59,57 @@ func main() {
 		b.RunningEnv = b.Development
 	}
 
-	r.OpenGameHallAndRooms(b.OpenRoomIDs)
+	if logFormat == "json" {
+		b.Log.SetFormat(log.JSONFormat)
+	}
+	if debug {
+		b.Log.SetMinLevel(log.DebugLevel)
+	}
+	if logDir != "" {
+		w, err := log.NewRotatingWriter(log.RotateConfig{
+			Dir:        logDir,
+			Prefix:     "barrage",
+			MaxSize:    logMaxSize << 20,
+			Daily:      true,
+			MaxBackups: logKeep,
+			Compress:   logCompress,
+		})
+		if err != nil {
+			b.Log.Fatalln(err)
+		}
+		defer w.Close()
+		defer w.ReopenOnSignal()()
+		b.Log.SetOutput(w, w)
+	}
+	if !logSync {
+		b.Log.EnableAsync(log.AsyncOptions{
+			BufferSize:       b.LogBufferSize,
+			Policy:           log.DropOldest,
+			DedupWindow:      b.LogDedupWindow,
+			SampleFirst:      b.LogSampleFirst,
+			SampleThereafter: b.LogSampleThereafter,
+		})
+		defer b.Log.Flush()
+	}
 
-	path := "/test"
+	statsStore, err := store.NewFileStore(statsPath)
+	if err != nil {
+		b.Log.Fatalln(err)
+	}
+	defer statsStore.Close()
+	r.SetStatsStore(statsStore)
+
+	// default settings of rooms are copied when rooms are created.
 	b.PlayGroundHeight = 200
 	b.PlayGroundWidth = 240
+	r.OpenGameHallAndRooms(b.OpenRoomIDs)
+
+	go admin.ListenAndServe(adminAddr)
+	if consolePath != "" {
+		go console.ListenAndServe(consolePath)
+	}
+
+	path := "/test"
 	if b.RunningEnv == b.Production {
 		path = "/ws"
 	}
@@ -29,6 +29,10 @@ const (
 	InfoAirplaneCreated
 	// InfoConnected is used when room done the connect for user.
 	InfoConnected
+	// InfoStats is used to send lifetime stats of a player to user.
+	InfoStats
+	// InfoRoomList is used to send visible rooms to user.
+	InfoRoomList
 
 	// User -> Room -----------------------------------------------------------
 
@@ -36,11 +40,30 @@ const (
 	InfoConnect
 	// InfoDisconnect is used when user want to leave game early(now, just leave room to hall).
 	InfoDisconnect
+	// InfoStatsQuery is used when user want to get lifetime stats of a player.
+	InfoStatsQuery
+	// InfoQuickPlay is used when user want to join a room chosen by hall.
+	InfoQuickPlay
+	// InfoRoomListQuery is used when user want to get visible rooms.
+	InfoRoomListQuery
 
 	// Room -> User, User -> Room -------------------------
 
 	// InfoPlayground is used when backend send balls info to frontend.
 	InfoPlayground
+	// InfoChat is used when user send chat message to room, and room route it to users.
+	InfoChat
+
+	// User -> frontend, frontend -> User, never reach Room -------------------
+
+	// InfoPing is used when user measure the latency of frontend.
+	InfoPing
+	// InfoPong is used when frontend reply ping.
+	InfoPong
+	// InfoClockSync is used when user tell frontend the latency and clock offset.
+	InfoClockSync
+	// InfoHeartbeat is used when frontend keep connection alive.
+	InfoHeartbeat
 )
 
 // Info is a interfase used as InfoPkg body.
@@ -64,6 +87,39 @@ type InfoList interface {
 	Crop(length uint32)
 }
 
+// itemSizer is implemented by InfoList knowing the least number of bytes of its items,
+// UnmarshalListBinary checks length of the list by it before allocating items.
+type itemSizer interface {
+	ItemMinSize() int
+}
+
+// checkListLength check the length of list read from untrusted bytes before allocating
+// items, it fails if the list is longer than b.InfoListMaxLength or its items can't fit
+// in remaining bytes.
+func checkListLength(length uint32, itemMinSize, remaining int) error {
+	if length > uint32(b.InfoListMaxLength) {
+		return fmt.Errorf("List is too long, hope at most %d, get %d.", b.InfoListMaxLength, length)
+	}
+	if need := int(length) * itemMinSize; need > remaining {
+		return fmt.Errorf("Bytes are not enough for %d items, hope %d, get %d.", length, need, remaining)
+	}
+	return nil
+}
+
+// checkStrLength check the length of string read from untrusted bytes.
+func checkStrLength(length int) error {
+	if length > b.InfoStringMaxLength {
+		return fmt.Errorf("String is too long, hope at most %d, get %d.", b.InfoStringMaxLength, length)
+	}
+	return nil
+}
+
+// readStr read a string prefixed by Uint8 length and check its length.
+func readStr(br *bufbo.BytesReader) (string, error) {
+	s := br.PrefixedStr(bufbo.PrefixUint8)
+	return s, checkStrLength(len(s))
+}
+
 // NewInfoPkgFromMsg ...
 func NewInfoPkgFromMsg(msg Message) (InfoPkg, error) {
 	var ipkg InfoPkg
@@ -78,6 +134,26 @@ func NewInfoPkgFromMsg(msg Message) (InfoPkg, error) {
 		ipkg = &ConnectedInfo{}
 	case MsgGameOver:
 		ipkg = &GameOverInfo{}
+	case MsgStats:
+		ipkg = &StatsInfo{}
+	case MsgStatsQuery:
+		ipkg = &StatsQueryInfo{}
+	case MsgChat:
+		ipkg = &ChatInfo{}
+	case MsgQuickPlay:
+		ipkg = &QuickPlayInfo{}
+	case MsgRoomList:
+		ipkg = &RoomListInfo{}
+	case MsgRoomListQuery:
+		ipkg = &RoomListQueryInfo{}
+	case MsgHeartbeat:
+		ipkg = &HeartbeatInfo{}
+	case MsgPing:
+		ipkg = &PingInfo{}
+	case MsgPong:
+		ipkg = &PongInfo{}
+	case MsgClockSync:
+		ipkg = &ClockSyncInfo{}
 	case MsgPlayground:
 		fallthrough
 	case MsgUserSelf:
@@ -126,9 +202,19 @@ func MarshalListBinary(infolist InfoList) ([]byte, error) {
 
 // UnmarshalListBinary unmarshal InfoList from bytes.
 func UnmarshalListBinary(infolist InfoList, bs []byte) (unmarshaledLength int, err error) {
-	br := bufbo.NewBEBytesReader(bs)
+	br := bufbo.NewBECheckedReader(bs)
 	length := br.Uint32()
+	if err := br.Err(); err != nil {
+		return 0, err
+	}
 
+	itemMinSize := 1
+	if is, ok := infolist.(itemSizer); ok {
+		itemMinSize = is.ItemMinSize()
+	}
+	if err := checkListLength(length, itemMinSize, br.Remaining()); err != nil {
+		return 4, err
+	}
 	infolist.NewItems(length)
 
 	unmarshaledLength = 4
@@ -136,8 +222,15 @@ func UnmarshalListBinary(infolist InfoList, bs []byte) (unmarshaledLength int, e
 	for i := uint32(0); i < length; i++ {
 		item := infolist.Item(int(count))
 		err := item.UnmarshalBinary(bs[unmarshaledLength:])
+		// the rest of bytes is broken if an item is cut off.
+		if err == bufbo.ErrNotEnough {
+			return unmarshaledLength, err
+		}
 		size := item.Size()
 		unmarshaledLength += size
+		if unmarshaledLength > len(bs) {
+			return unmarshaledLength, bufbo.ErrNotEnough
+		}
 
 		// ignore and drop fail marshaled bytes.
 		if err != nil {
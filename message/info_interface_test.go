@@ -199,3 +199,26 @@ func TestMarshalListBinaryError(t *testing.T) {
 	}
 
 }
+
+// TestUnmarshalListBinaryLimits ...
+func TestUnmarshalListBinaryLimits(t *testing.T) {
+	oldLimit := b.InfoListMaxLength
+	b.InfoListMaxLength = 3
+	defer func() { b.InfoListMaxLength = oldLimit }()
+
+	til := &testInfoList{}
+	if _, err := UnmarshalListBinary(til, generateTestBytes()); err == nil {
+		t.Error("List longer than InfoListMaxLength should not be unmarshaled.")
+	}
+
+	// a huge length with few bytes is rejected before allocating.
+	b.InfoListMaxLength = oldLimit
+	bsi := &BallsInfo{}
+	bs := []byte{0, 0, 0x0f, 0xff, 1, 2, 3}
+	if _, err := UnmarshalListBinary(bsi, bs); err == nil {
+		t.Error("List whose items can't fit in bytes should not be unmarshaled.")
+	}
+	if bsi.BallInfos != nil {
+		t.Errorf("Items should not be allocated, get %d items.", len(bsi.BallInfos))
+	}
+}
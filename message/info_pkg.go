@@ -5,7 +5,7 @@ import (
 	"barrage-server/libs/bufbo"
 	"bytes"
 	"fmt"
-	"math"
+	"time"
 )
 
 // GameOverInfo send information from Room to User while server gonna shutdown.
@@ -36,8 +36,10 @@ func (goi *GameOverInfo) MarshalBinary() ([]byte, error) {
 
 // UnmarshalBinary unmarshal GameOverInfo from bytes
 func (goi *GameOverInfo) UnmarshalBinary(bs []byte) error {
-	goi.Overtype = bs[0]
-	return nil
+	br := bufbo.NewBECheckedReader(bs)
+
+	goi.Overtype = br.Uint8()
+	return br.Err()
 }
 
 // SpecialMsgInfo send information from Room to User while special message generated.
@@ -62,27 +64,28 @@ func (smi *SpecialMsgInfo) Size() int {
 
 // MarshalBinary marshal SpecialMsgInfo to bytes
 func (smi *SpecialMsgInfo) MarshalBinary() ([]byte, error) {
-	msgBytes := []byte(smi.Message)
-	msgLen := smi.Size() - 1
-
-	if msgLen > math.MaxUint8 {
-		return nil, fmt.Errorf("SpecialMsgInfo MarshalError: Special message is too long, hope 255, get %d.", msgLen)
+	msgLen := len(smi.Message)
+	if msgLen > b.InfoStringMaxLength {
+		return nil, fmt.Errorf("SpecialMsgInfo MarshalError: Special message is too long, hope at most %d, get %d.", b.InfoStringMaxLength, msgLen)
 	}
 
-	bs := make([]byte, msgLen+1)
-	bs[0] = uint8(msgLen)
-	copy(bs[1:], msgBytes)
+	bs := make([]byte, smi.Size())
+	bw := bufbo.NewBEBytesWriter(bs)
+	bw.PutPrefixedStr(smi.Message, bufbo.PrefixUint8)
 
 	return bs, nil
 }
 
 // UnmarshalBinary unmarshal SpecialMsgInfo from bytes
 func (smi *SpecialMsgInfo) UnmarshalBinary(bs []byte) error {
-	br := bufbo.NewBEBytesReader(bs)
+	br := bufbo.NewBECheckedReader(bs)
 
-	smi.Message = br.Str(int(br.Uint8()))
+	var err error
+	if smi.Message, err = readStr(br); err != nil {
+		return err
+	}
 
-	return nil
+	return br.Err()
 }
 
 // DisconnectInfo send information from User to Room while user disconnecting
@@ -123,19 +126,31 @@ func (di *DisconnectInfo) MarshalBinary() ([]byte, error) {
 
 // UnmarshalBinary unmarshal DisconnectInfo from bytes
 func (di *DisconnectInfo) UnmarshalBinary(bs []byte) error {
-	br := bufbo.NewBEBytesReader(bs)
+	br := bufbo.NewBECheckedReader(bs)
 
 	di.UID = b.UserID(br.Uint32())
 	di.RID = b.RoomID(br.Uint32())
 
-	return nil
+	return br.Err()
 }
 
 // ConnectedInfo send information from User to Room while user joining
 // game.
+//
+// Other fields are the settings of the room, TickDuration is the number of milliseconds
+// between two playground infos.
 type ConnectedInfo struct {
 	UID b.UserID
 	RID b.RoomID
+
+	MembersLimit uint8
+	Width        uint16
+	Height       uint16
+	TickDuration uint16
+	Mode         b.GameMode
+	FriendlyFire bool
+	Background   b.ImageID
+	Name         string
 }
 
 // Type return type of information
@@ -150,35 +165,61 @@ func (ci *ConnectedInfo) Body() Info {
 
 // Size return the number of bytes after marshaled.
 func (ci *ConnectedInfo) Size() int {
-	return 8
+	return 19 + len(ci.Name)
 }
 
 // MarshalBinary marshal ConnectedInfo to bytes
 func (ci *ConnectedInfo) MarshalBinary() ([]byte, error) {
+	nameLen := len(ci.Name)
+	if nameLen > b.InfoStringMaxLength {
+		return nil, fmt.Errorf("ConnectedInfo MarshalError: Name is too long, hope at most %d, get %d.", b.InfoStringMaxLength, nameLen)
+	}
+
 	bs := make([]byte, ci.Size())
 	bw := bufbo.NewBEBytesWriter(bs)
 
 	bw.PutUint32(uint32(ci.UID))
 	bw.PutUint32(uint32(ci.RID))
+	bw.PutUint8(ci.MembersLimit)
+	bw.PutUint16(ci.Width)
+	bw.PutUint16(ci.Height)
+	bw.PutUint16(ci.TickDuration)
+	bw.PutUint8(uint8(ci.Mode))
+	bw.PutBool(ci.FriendlyFire)
+	bw.PutUint8(uint8(ci.Background))
+	bw.PutPrefixedStr(ci.Name, bufbo.PrefixUint8)
 
 	return bs, nil
 }
 
 // UnmarshalBinary unmarshal ConnectedInfo from bytes
 func (ci *ConnectedInfo) UnmarshalBinary(bs []byte) error {
-	br := bufbo.NewBEBytesReader(bs)
+	br := bufbo.NewBECheckedReader(bs)
 
 	ci.UID = b.UserID(br.Uint32())
 	ci.RID = b.RoomID(br.Uint32())
+	ci.MembersLimit = br.Uint8()
+	ci.Width = br.Uint16()
+	ci.Height = br.Uint16()
+	ci.TickDuration = br.Uint16()
+	ci.Mode = b.GameMode(br.Uint8())
+	ci.FriendlyFire = br.Bool()
+	ci.Background = b.ImageID(br.Uint8())
+	var err error
+	if ci.Name, err = readStr(br); err != nil {
+		return err
+	}
 
-	return nil
+	return br.Err()
 }
 
 // ConnectInfo send information from User to Room while user joining
-// game.
+// game. Credential is the password or invite code of a private room, it is
+// optional and only marshaled when it is not empty.
 type ConnectInfo struct {
-	UID b.UserID
-	RID b.RoomID
+	UID        b.UserID
+	RID        b.RoomID
+	Credential string
 }
 
 // Type return type of information
@@ -193,28 +234,200 @@ func (ci *ConnectInfo) Body() Info {
 
 // Size return the number of bytes after marshaled.
 func (ci *ConnectInfo) Size() int {
-	return 8
+	if ci.Credential == "" {
+		return 8
+	}
+	return 9 + len(ci.Credential)
 }
 
 // MarshalBinary marshal ConnectInfo to bytes
 func (ci *ConnectInfo) MarshalBinary() ([]byte, error) {
+	credentialLen := len(ci.Credential)
+	if credentialLen > b.InfoStringMaxLength {
+		return nil, fmt.Errorf("ConnectInfo MarshalError: Credential is too long, hope at most %d, get %d.", b.InfoStringMaxLength, credentialLen)
+	}
+
 	bs := make([]byte, ci.Size())
 	bw := bufbo.NewBEBytesWriter(bs)
 
 	bw.PutUint32(uint32(ci.UID))
 	bw.PutUint32(uint32(ci.RID))
+	if credentialLen > 0 {
+		bw.PutPrefixedStr(ci.Credential, bufbo.PrefixUint8)
+	}
 
 	return bs, nil
 }
 
 // UnmarshalBinary unmarshal ConnectInfo from bytes
 func (ci *ConnectInfo) UnmarshalBinary(bs []byte) error {
-	br := bufbo.NewBEBytesReader(bs)
+	br := bufbo.NewBECheckedReader(bs)
 
 	ci.UID = b.UserID(br.Uint32())
 	ci.RID = b.RoomID(br.Uint32())
+	ci.Credential = ""
+	if len(bs) > 8 {
+		var err error
+		if ci.Credential, err = readStr(br); err != nil {
+			return err
+		}
+	}
 
-	return nil
+	return br.Err()
+}
+
+// StatsQueryInfo send information from User to Room while user querying the lifetime
+// stats of the player Target.
+type StatsQueryInfo struct {
+	UID    b.UserID
+	Target b.UserID
+}
+
+// Type return type of information
+func (sqi *StatsQueryInfo) Type() InfoType {
+	return InfoStatsQuery
+}
+
+// Body return StatsQueryInfo self.
+func (sqi *StatsQueryInfo) Body() Info {
+	return sqi
+}
+
+// Size return the number of bytes after marshaled.
+func (sqi *StatsQueryInfo) Size() int {
+	return 8
+}
+
+// MarshalBinary marshal StatsQueryInfo to bytes
+func (sqi *StatsQueryInfo) MarshalBinary() ([]byte, error) {
+	bs := make([]byte, sqi.Size())
+	bw := bufbo.NewBEBytesWriter(bs)
+
+	bw.PutUint32(uint32(sqi.UID))
+	bw.PutUint32(uint32(sqi.Target))
+
+	return bs, nil
+}
+
+// UnmarshalBinary unmarshal StatsQueryInfo from bytes
+func (sqi *StatsQueryInfo) UnmarshalBinary(bs []byte) error {
+	br := bufbo.NewBECheckedReader(bs)
+
+	sqi.UID = b.UserID(br.Uint32())
+	sqi.Target = b.UserID(br.Uint32())
+
+	return br.Err()
+}
+
+// QuickPlayInfo send information from User to Hall while user joining a room chosen
+// by hall. Empty Region means any region. Skill is ignored by server, which rates user
+// by its lifetime stats, it is kept for compatibility.
+type QuickPlayInfo struct {
+	UID    b.UserID
+	Region string
+	Skill  uint16
+}
+
+// Type return type of information
+func (qpi *QuickPlayInfo) Type() InfoType {
+	return InfoQuickPlay
+}
+
+// Body return QuickPlayInfo self.
+func (qpi *QuickPlayInfo) Body() Info {
+	return qpi
+}
+
+// Size return the number of bytes after marshaled.
+func (qpi *QuickPlayInfo) Size() int {
+	return 7 + len(qpi.Region)
+}
+
+// MarshalBinary marshal QuickPlayInfo to bytes
+func (qpi *QuickPlayInfo) MarshalBinary() ([]byte, error) {
+	regionLen := len(qpi.Region)
+	if regionLen > b.InfoStringMaxLength {
+		return nil, fmt.Errorf("QuickPlayInfo MarshalError: Region is too long, hope at most %d, get %d.", b.InfoStringMaxLength, regionLen)
+	}
+
+	bs := make([]byte, qpi.Size())
+	bw := bufbo.NewBEBytesWriter(bs)
+
+	bw.PutUint32(uint32(qpi.UID))
+	bw.PutPrefixedStr(qpi.Region, bufbo.PrefixUint8)
+	bw.PutUint16(qpi.Skill)
+
+	return bs, nil
+}
+
+// UnmarshalBinary unmarshal QuickPlayInfo from bytes
+func (qpi *QuickPlayInfo) UnmarshalBinary(bs []byte) error {
+	br := bufbo.NewBECheckedReader(bs)
+
+	qpi.UID = b.UserID(br.Uint32())
+	var err error
+	if qpi.Region, err = readStr(br); err != nil {
+		return err
+	}
+	qpi.Skill = br.Uint16()
+
+	return br.Err()
+}
+
+// StatsInfo send information from Room to User while replying StatsQueryInfo, holding
+// the lifetime stats of a player.
+type StatsInfo struct {
+	UID     b.UserID
+	Matches uint32
+	Kills   uint32
+	Deaths  uint32
+	Damage  uint64
+	// TimePlayed is the number of seconds.
+	TimePlayed uint32
+}
+
+// Type return type of information
+func (si *StatsInfo) Type() InfoType {
+	return InfoStats
+}
+
+// Body return StatsInfo self.
+func (si *StatsInfo) Body() Info {
+	return si
+}
+
+// Size return the number of bytes after marshaled.
+func (si *StatsInfo) Size() int {
+	return 28
+}
+
+// MarshalBinary marshal StatsInfo to bytes
+func (si *StatsInfo) MarshalBinary() ([]byte, error) {
+	bs := make([]byte, si.Size())
+	bw := bufbo.NewBEBytesWriter(bs)
+
+	bw.PutUint32(uint32(si.UID))
+	bw.PutUint32(si.Matches)
+	bw.PutUint32(si.Kills)
+	bw.PutUint32(si.Deaths)
+	bw.PutUint64(si.Damage)
+	bw.PutUint32(si.TimePlayed)
+
+	return bs, nil
+}
+
+// UnmarshalBinary unmarshal StatsInfo from bytes
+func (si *StatsInfo) UnmarshalBinary(bs []byte) error {
+	br := bufbo.NewBECheckedReader(bs)
+
+	si.UID = b.UserID(br.Uint32())
+	si.Matches = br.Uint32()
+	si.Kills = br.Uint32()
+	si.Deaths = br.Uint32()
+	si.Damage = br.Uint64()
+	si.TimePlayed = br.Uint32()
+
+	return br.Err()
 }
 
 // PlaygroundInfo exchange informations among User, Room and Playground.
@@ -223,6 +436,10 @@ type PlaygroundInfo struct {
 	Receiver   b.UserID
 	CacheBytes []byte
 
+	// Timestamp is the time of playground perceived by Sender in the clock of server,
+	// it is set by User and never marshaled.
+	Timestamp time.Time
+
 	NewBalls      *BallsInfo
 	Displacements *BallsInfo
 	Collisions    *CollisionsInfo
@@ -339,3 +556,380 @@ func (pi *PlaygroundInfo) UnmarshalBinary(bs []byte) error {
 
 	return nil
 }
+
+const (
+	// ChatRoom means chat message is sent to all users in room.
+	ChatRoom = uint8(iota)
+	// ChatTeam means chat message is sent to users in the team of sender.
+	ChatTeam
+)
+
+// ChatInfo exchange chat message between User and Room, UID is the sender of message.
+type ChatInfo struct {
+	UID     b.UserID
+	Channel uint8
+	Message string
+}
+
+// Type return type of information
+func (ci *ChatInfo) Type() InfoType {
+	return InfoChat
+}
+
+// Body return ChatInfo self.
+func (ci *ChatInfo) Body() Info {
+	return ci
+}
+
+// Size return the number of bytes after marshaled.
+func (ci *ChatInfo) Size() int {
+	return 6 + len(ci.Message)
+}
+
+// MarshalBinary marshal ChatInfo to bytes
+func (ci *ChatInfo) MarshalBinary() ([]byte, error) {
+	msgLen := len(ci.Message)
+	if msgLen > b.InfoStringMaxLength {
+		return nil, fmt.Errorf("ChatInfo MarshalError: Chat message is too long, hope at most %d, get %d.", b.InfoStringMaxLength, msgLen)
+	}
+
+	bs := make([]byte, ci.Size())
+	bw := bufbo.NewBEBytesWriter(bs)
+
+	bw.PutUint32(uint32(ci.UID))
+	bw.PutUint8(ci.Channel)
+	bw.PutPrefixedStr(ci.Message, bufbo.PrefixUint8)
+
+	return bs, nil
+}
+
+// UnmarshalBinary unmarshal ChatInfo from bytes
+func (ci *ChatInfo) UnmarshalBinary(bs []byte) error {
+	br := bufbo.NewBECheckedReader(bs)
+
+	ci.UID = b.UserID(br.Uint32())
+	ci.Channel = br.Uint8()
+	var err error
+	if ci.Message, err = readStr(br); err != nil {
+		return err
+	}
+
+	return br.Err()
+}
+
+// PingInfo send information from User to frontend while measuring latency, ServerTime
+// is the number of nanoseconds elapsed since January 1, 1970 UTC.
+type PingInfo struct {
+	Seq        uint32
+	ServerTime float64
+}
+
+// Type return type of information
+func (pi *PingInfo) Type() InfoType {
+	return InfoPing
+}
+
+// Body return PingInfo self.
+func (pi *PingInfo) Body() Info {
+	return pi
+}
+
+// Size return the number of bytes after marshaled.
+func (pi *PingInfo) Size() int {
+	return 12
+}
+
+// MarshalBinary marshal PingInfo to bytes
+func (pi *PingInfo) MarshalBinary() ([]byte, error) {
+	bs := make([]byte, pi.Size())
+	bw := bufbo.NewBEBytesWriter(bs)
+
+	bw.PutUint32(pi.Seq)
+	bw.PutFloat64(pi.ServerTime)
+
+	return bs, nil
+}
+
+// UnmarshalBinary unmarshal PingInfo from bytes
+func (pi *PingInfo) UnmarshalBinary(bs []byte) error {
+	br := bufbo.NewBECheckedReader(bs)
+
+	pi.Seq = br.Uint32()
+	pi.ServerTime = br.Float64()
+
+	return br.Err()
+}
+
+// PongInfo send information from frontend to User while replying PingInfo, the
+// timestamp of the message is the time of frontend.
+type PongInfo struct {
+	Seq uint32
+}
+
+// Type return type of information
+func (pi *PongInfo) Type() InfoType {
+	return InfoPong
+}
+
+// Body return PongInfo self.
+func (pi *PongInfo) Body() Info {
+	return pi
+}
+
+// Size return the number of bytes after marshaled.
+func (pi *PongInfo) Size() int {
+	return 4
+}
+
+// MarshalBinary marshal PongInfo to bytes
+func (pi *PongInfo) MarshalBinary() ([]byte, error) {
+	bs := make([]byte, pi.Size())
+	bw := bufbo.NewBEBytesWriter(bs)
+
+	bw.PutUint32(pi.Seq)
+
+	return bs, nil
+}
+
+// UnmarshalBinary unmarshal PongInfo from bytes
+func (pi *PongInfo) UnmarshalBinary(bs []byte) error {
+	br := bufbo.NewBECheckedReader(bs)
+
+	pi.Seq = br.Uint32()
+
+	return br.Err()
+}
+
+// ClockSyncInfo send information from User to frontend after measuring latency, RTT
+// and Offset are numbers of nanoseconds. Offset is the clock of frontend minus the
+// clock of server, so server time is the time of frontend minus Offset.
+type ClockSyncInfo struct {
+	RTT    float64
+	Offset float64
+}
+
+// Type return type of information
+func (csi *ClockSyncInfo) Type() InfoType {
+	return InfoClockSync
+}
+
+// Body return ClockSyncInfo self.
+func (csi *ClockSyncInfo) Body() Info {
+	return csi
+}
+
+// Size return the number of bytes after marshaled.
+func (csi *ClockSyncInfo) Size() int {
+	return 16
+}
+
+// MarshalBinary marshal ClockSyncInfo to bytes
+func (csi *ClockSyncInfo) MarshalBinary() ([]byte, error) {
+	bs := make([]byte, csi.Size())
+	bw := bufbo.NewBEBytesWriter(bs)
+
+	bw.PutFloat64(csi.RTT)
+	bw.PutFloat64(csi.Offset)
+
+	return bs, nil
+}
+
+// UnmarshalBinary unmarshal ClockSyncInfo from bytes
+func (csi *ClockSyncInfo) UnmarshalBinary(bs []byte) error {
+	br := bufbo.NewBECheckedReader(bs)
+
+	csi.RTT = br.Float64()
+	csi.Offset = br.Float64()
+
+	return br.Err()
+}
+
+// RoomListQueryInfo send information from User to Hall while user querying visible
+// rooms, if Subscribe is true, hall pushes RoomListInfo to user when rooms change.
+type RoomListQueryInfo struct {
+	UID       b.UserID
+	Subscribe bool
+}
+
+// Type return type of information
+func (rlqi *RoomListQueryInfo) Type() InfoType {
+	return InfoRoomListQuery
+}
+
+// Body return RoomListQueryInfo self.
+func (rlqi *RoomListQueryInfo) Body() Info {
+	return rlqi
+}
+
+// Size return the number of bytes after marshaled.
+func (rlqi *RoomListQueryInfo) Size() int {
+	return 5
+}
+
+// MarshalBinary marshal RoomListQueryInfo to bytes
+func (rlqi *RoomListQueryInfo) MarshalBinary() ([]byte, error) {
+	bs := make([]byte, rlqi.Size())
+	bw := bufbo.NewBEBytesWriter(bs)
+
+	bw.PutUint32(uint32(rlqi.UID))
+	bw.PutBool(rlqi.Subscribe)
+
+	return bs, nil
+}
+
+// UnmarshalBinary unmarshal RoomListQueryInfo from bytes
+func (rlqi *RoomListQueryInfo) UnmarshalBinary(bs []byte) error {
+	br := bufbo.NewBECheckedReader(bs)
+
+	rlqi.UID = b.UserID(br.Uint32())
+	rlqi.Subscribe = br.Bool()
+
+	return br.Err()
+}
+
+// RoomSummary is the brief of a room shown in lobby.
+type RoomSummary struct {
+	RID    b.RoomID
+	Name   string
+	Count  uint8
+	Limit  uint8
+	Mode   b.GameMode
+	Status uint8
+}
+
+// Size return the number of bytes after marshaled.
+func (rs *RoomSummary) Size() int {
+	return 9 + len(rs.Name)
+}
+
+// MarshalBinary marshal RoomSummary to bytes
+func (rs *RoomSummary) MarshalBinary() ([]byte, error) {
+	nameLen := len(rs.Name)
+	if nameLen > b.InfoStringMaxLength {
+		return nil, fmt.Errorf("RoomSummary MarshalError: Name is too long, hope at most %d, get %d.", b.InfoStringMaxLength, nameLen)
+	}
+
+	bs := make([]byte, rs.Size())
+	bw := bufbo.NewBEBytesWriter(bs)
+
+	bw.PutUint32(uint32(rs.RID))
+	bw.PutPrefixedStr(rs.Name, bufbo.PrefixUint8)
+	bw.PutUint8(rs.Count)
+	bw.PutUint8(rs.Limit)
+	bw.PutUint8(uint8(rs.Mode))
+	bw.PutUint8(rs.Status)
+
+	return bs, nil
+}
+
+// UnmarshalBinary unmarshal RoomSummary from bytes
+func (rs *RoomSummary) UnmarshalBinary(bs []byte) error {
+	br := bufbo.NewBECheckedReader(bs)
+
+	rs.RID = b.RoomID(br.Uint32())
+	var err error
+	if rs.Name, err = readStr(br); err != nil {
+		return err
+	}
+	rs.Count = br.Uint8()
+	rs.Limit = br.Uint8()
+	rs.Mode = b.GameMode(br.Uint8())
+	rs.Status = br.Uint8()
+
+	return br.Err()
+}
+
+// RoomListInfo send information from Hall to User, holding summaries of all visible rooms.
+type RoomListInfo struct {
+	Rooms []*RoomSummary
+}
+
+// Type return type of information
+func (rli *RoomListInfo) Type() InfoType {
+	return InfoRoomList
+}
+
+// Body return RoomListInfo self.
+func (rli *RoomListInfo) Body() Info {
+	return rli
+}
+
+// Length return length
+func (rli *RoomListInfo) Length() int {
+	return len(rli.Rooms)
+}
+
+// Item return item of Rooms.
+func (rli *RoomListInfo) Item(index int) b.CommunicationData {
+	return rli.Rooms[index]
+}
+
+// ItemMinSize return the size of RoomSummary without name.
+func (rli *RoomListInfo) ItemMinSize() int {
+	return (&RoomSummary{}).Size()
+}
+
+// NewItems init Rooms
+func (rli *RoomListInfo) NewItems(length uint32) {
+	rli.Rooms = make([]*RoomSummary, length)
+	for i := range rli.Rooms {
+		rli.Rooms[i] = new(RoomSummary)
+	}
+}
+
+// Crop crop Rooms
+func (rli *RoomListInfo) Crop(length uint32) {
+	rli.Rooms = rli.Rooms[:length]
+}
+
+// Size return the number of bytes after marshaled.
+func (rli *RoomListInfo) Size() int {
+	sum := 4
+	for _, rs := range rli.Rooms {
+		sum += rs.Size()
+	}
+	return sum
+}
+
+// MarshalBinary marshal RoomListInfo to bytes
+func (rli *RoomListInfo) MarshalBinary() ([]byte, error) {
+	return MarshalListBinary(rli)
+}
+
+// UnmarshalBinary unmarshal RoomListInfo from bytes, an empty list is valid.
+func (rli *RoomListInfo) UnmarshalBinary(bs []byte) error {
+	_, err := UnmarshalListBinary(rli, bs)
+	if err == ErrEmptyInfo {
+		rli.Rooms = rli.Rooms[:0]
+		return nil
+	}
+	return err
+}
+
+// HeartbeatInfo send from frontend to User to keep connection alive, it has no body.
+type HeartbeatInfo struct{}
+
+// Type return type of information
+func (hi *HeartbeatInfo) Type() InfoType {
+	return InfoHeartbeat
+}
+
+// Body return HeartbeatInfo self.
+func (hi *HeartbeatInfo) Body() Info {
+	return hi
+}
+
+// Size return the number of bytes after marshaled.
+func (hi *HeartbeatInfo) Size() int {
+	return 0
+}
+
+// MarshalBinary marshal HeartbeatInfo to bytes
+func (hi *HeartbeatInfo) MarshalBinary() ([]byte, error) {
+	return []byte{}, nil
+}
+
+// UnmarshalBinary unmarshal HeartbeatInfo from bytes
+func (hi *HeartbeatInfo) UnmarshalBinary(bs []byte) error {
+	return nil
+}
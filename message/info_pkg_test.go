@@ -5,6 +5,7 @@ import (
 	b "barrage-server/base"
 	"bytes"
 	"testing"
+	"time"
 )
 
 const (
@@ -184,7 +185,7 @@ func TestDisconnectInfo(t *testing.T) {
 // TestConnectedInfo ...
 func TestConnectedInfo(t *testing.T) {
 	// MarshalBinary
-	ci := &ConnectedInfo{b.UserID(666666), b.RoomID(1)}
+	ci := &ConnectedInfo{UID: b.UserID(666666), RID: b.RoomID(1)}
 	bs, err := ci.MarshalBinary()
 	if err != nil {
 		t.Error(err)
@@ -203,12 +204,32 @@ func TestConnectedInfo(t *testing.T) {
 	if rid := ci.RID; rid != b.RoomID(1) {
 		t.Errorf("Room Id of Unmarshaled ConnectedInfo should be %v, but get %v.", b.RoomID(1), rid)
 	}
+
+	// with settings of room
+	ci = &ConnectedInfo{
+		UID: 1, RID: 2, MembersLimit: 8, Width: 3000, Height: 2100, TickDuration: 40,
+		Mode: b.TeamBattle, FriendlyFire: true, Background: 3, Name: "Room 2",
+	}
+	bs, err = ci.MarshalBinary()
+	if err != nil {
+		t.Error(err)
+	}
+	if l1, l2 := len(bs), ci.Size(); l1 != l2 {
+		t.Errorf("Result of Marshaled bytes is not correct, hope %d, get %d.", l2, l1)
+	}
+	ciBak := &ConnectedInfo{}
+	if err := ciBak.UnmarshalBinary(bs); err != nil {
+		t.Error(err)
+	}
+	if *ciBak != *ci {
+		t.Errorf("Unmarshaled ConnectedInfo should be %+v, but get %+v.", *ci, *ciBak)
+	}
 }
 
 // TestConnectInfo ...
 func TestConnectInfo(t *testing.T) {
 	// MarshalBinary
-	ci := &ConnectInfo{b.UserID(666666), b.RoomID(1)}
+	ci := &ConnectInfo{UID: b.UserID(666666), RID: b.RoomID(1)}
 	bs, err := ci.MarshalBinary()
 	if err != nil {
 		t.Error(err)
@@ -227,6 +248,98 @@ func TestConnectInfo(t *testing.T) {
 	if rid := ci.RID; rid != b.RoomID(1) {
 		t.Errorf("Room Id of Unmarshaled ConnectInfo should be %v, but get %v.", b.RoomID(1), rid)
 	}
+
+	// with credential
+	ci.Credential = "secret"
+	bs, err = ci.MarshalBinary()
+	if err != nil {
+		t.Error(err)
+	}
+	if l1, l2 := len(bs), ci.Size(); l1 != l2 {
+		t.Errorf("Result of Marshaled bytes is not correct, hope %d, get %d.", l2, l1)
+	}
+	ciBak := &ConnectInfo{}
+	if err := ciBak.UnmarshalBinary(bs); err != nil {
+		t.Error(err)
+	}
+	if *ciBak != *ci {
+		t.Errorf("Unmarshaled ConnectInfo should be %+v, but get %+v.", *ci, *ciBak)
+	}
+}
+
+// TestStatsQueryInfo ...
+func TestStatsQueryInfo(t *testing.T) {
+	// MarshalBinary
+	sqi := &StatsQueryInfo{b.UserID(666666), b.UserID(233)}
+	bs, err := sqi.MarshalBinary()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if l1, l2 := len(bs), sqi.Size(); l1 != l2 {
+		t.Errorf("Result of Marshaled bytes is not correct, hope %d, get %d.", l2, l1)
+	}
+
+	// UnmarshalBinary
+	sqi = &StatsQueryInfo{}
+	err = sqi.UnmarshalBinary(bs)
+	if uid := sqi.UID; uid != b.UserID(666666) {
+		t.Errorf("User Id of Unmarshaled StatsQueryInfo should be %v, but get %v.", b.UserID(666666), uid)
+	}
+	if target := sqi.Target; target != b.UserID(233) {
+		t.Errorf("Target of Unmarshaled StatsQueryInfo should be %v, but get %v.", b.UserID(233), target)
+	}
+}
+
+// TestQuickPlayInfo ...
+func TestQuickPlayInfo(t *testing.T) {
+	qpi := &QuickPlayInfo{UID: b.UserID(666666), Region: "asia", Skill: 1500}
+	bs, err := qpi.MarshalBinary()
+	if err != nil {
+		t.Error(err)
+	}
+	if l1, l2 := len(bs), qpi.Size(); l1 != l2 {
+		t.Errorf("Result of Marshaled bytes is not correct, hope %d, get %d.", l2, l1)
+	}
+
+	qpiBak := &QuickPlayInfo{}
+	if err := qpiBak.UnmarshalBinary(bs); err != nil {
+		t.Error(err)
+	}
+	if *qpiBak != *qpi {
+		t.Errorf("Unmarshaled QuickPlayInfo should be %+v, but get %+v.", *qpi, *qpiBak)
+	}
+}
+
+// TestStatsInfo ...
+func TestStatsInfo(t *testing.T) {
+	// MarshalBinary
+	si := &StatsInfo{
+		UID:        b.UserID(666666),
+		Matches:    3,
+		Kills:      10,
+		Deaths:     2,
+		Damage:     1 << 40,
+		TimePlayed: 3600,
+	}
+	bs, err := si.MarshalBinary()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if l1, l2 := len(bs), si.Size(); l1 != l2 {
+		t.Errorf("Result of Marshaled bytes is not correct, hope %d, get %d.", l2, l1)
+	}
+
+	// UnmarshalBinary
+	siBak := &StatsInfo{}
+	err = siBak.UnmarshalBinary(bs)
+	if err != nil {
+		t.Error(err)
+	}
+	if *siBak != *si {
+		t.Errorf("Unmarshaled StatsInfo should be %+v, but get %+v.", *si, *siBak)
+	}
 }
 
 // TestPlaygroundInfo ...
@@ -264,3 +377,207 @@ func TestPlaygroundInfo(t *testing.T) {
 		t.Errorf("Length of PlaygroundInfo Disappears should be %d, but get %d.", 99, dsiLen)
 	}
 }
+
+// TestChatInfo ...
+func TestChatInfo(t *testing.T) {
+	// MarshalBinary
+	ci := &ChatInfo{UID: b.UserID(666666), Channel: ChatTeam, Message: "Hello, barrage!"}
+	bs, err := ci.MarshalBinary()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if l1, l2 := len(bs), ci.Size(); l1 != l2 {
+		t.Errorf("Result of Marshaled bytes is not correct, hope %d, get %d.", l2, l1)
+	}
+
+	// UnmarshalBinary
+	ciBak := &ChatInfo{}
+	err = ciBak.UnmarshalBinary(bs)
+	if err != nil {
+		t.Error(err)
+	}
+	if *ciBak != *ci {
+		t.Errorf("Unmarshaled ChatInfo should be %+v, but get %+v.", *ci, *ciBak)
+	}
+
+	// too long
+	ci.Message = string(make([]byte, 256))
+	if _, err := ci.MarshalBinary(); err == nil {
+		t.Error("ChatInfo with too long message should fail to be marshaled.")
+	}
+}
+
+// TestPingInfoAndPongInfo ...
+func TestPingInfoAndPongInfo(t *testing.T) {
+	pi := &PingInfo{Seq: 7, ServerTime: float64(time.Now().UnixNano())}
+	bs, err := pi.MarshalBinary()
+	if err != nil {
+		t.Error(err)
+	}
+	if l1, l2 := len(bs), pi.Size(); l1 != l2 {
+		t.Errorf("Result of Marshaled bytes is not correct, hope %d, get %d.", l2, l1)
+	}
+
+	piBak := &PingInfo{}
+	if err := piBak.UnmarshalBinary(bs); err != nil {
+		t.Error(err)
+	}
+	if *piBak != *pi {
+		t.Errorf("Unmarshaled PingInfo should be %+v, but get %+v.", *pi, *piBak)
+	}
+
+	po := &PongInfo{Seq: 7}
+	bs, _ = po.MarshalBinary()
+	poBak := &PongInfo{}
+	if err := poBak.UnmarshalBinary(bs); err != nil {
+		t.Error(err)
+	}
+	if *poBak != *po {
+		t.Errorf("Unmarshaled PongInfo should be %+v, but get %+v.", *po, *poBak)
+	}
+}
+
+// TestClockSyncInfo ...
+func TestClockSyncInfo(t *testing.T) {
+	csi := &ClockSyncInfo{RTT: 2e7, Offset: -3.5e9}
+	bs, err := csi.MarshalBinary()
+	if err != nil {
+		t.Error(err)
+	}
+	if l1, l2 := len(bs), csi.Size(); l1 != l2 {
+		t.Errorf("Result of Marshaled bytes is not correct, hope %d, get %d.", l2, l1)
+	}
+
+	csiBak := &ClockSyncInfo{}
+	if err := csiBak.UnmarshalBinary(bs); err != nil {
+		t.Error(err)
+	}
+	if *csiBak != *csi {
+		t.Errorf("Unmarshaled ClockSyncInfo should be %+v, but get %+v.", *csi, *csiBak)
+	}
+}
+
+// TestRoomListQueryInfo ...
+func TestRoomListQueryInfo(t *testing.T) {
+	rlqi := &RoomListQueryInfo{UID: 20, Subscribe: true}
+	bs, err := rlqi.MarshalBinary()
+	if err != nil {
+		t.Error(err)
+	}
+	if l1, l2 := len(bs), rlqi.Size(); l1 != l2 {
+		t.Errorf("Result of Marshaled bytes is not correct, hope %d, get %d.", l2, l1)
+	}
+
+	rlqiBak := &RoomListQueryInfo{}
+	if err := rlqiBak.UnmarshalBinary(bs); err != nil {
+		t.Error(err)
+	}
+	if *rlqiBak != *rlqi {
+		t.Errorf("Unmarshaled RoomListQueryInfo should be %+v, but get %+v.", *rlqi, *rlqiBak)
+	}
+}
+
+// TestRoomListInfo ...
+func TestRoomListInfo(t *testing.T) {
+	rli := &RoomListInfo{Rooms: []*RoomSummary{
+		{RID: 1, Name: "Room 1", Count: 3, Limit: 8, Mode: b.FreeForAll, Status: 1},
+		{RID: 2, Name: "Team", Count: 0, Limit: 4, Mode: b.TeamBattle, Status: 0},
+	}}
+	bs, err := rli.MarshalBinary()
+	if err != nil {
+		t.Error(err)
+	}
+	if l1, l2 := len(bs), rli.Size(); l1 != l2 {
+		t.Errorf("Result of Marshaled bytes is not correct, hope %d, get %d.", l2, l1)
+	}
+
+	rliBak := &RoomListInfo{}
+	if err := rliBak.UnmarshalBinary(bs); err != nil {
+		t.Error(err)
+	}
+	if l := rliBak.Length(); l != 2 {
+		t.Fatalf("Length of rooms is wrong, hope %d, get %d.", 2, l)
+	}
+	for i, rs := range rliBak.Rooms {
+		if *rs != *rli.Rooms[i] {
+			t.Errorf("Unmarshaled RoomSummary should be %+v, but get %+v.", *rli.Rooms[i], *rs)
+		}
+	}
+
+	// empty list
+	bs, _ = (&RoomListInfo{}).MarshalBinary()
+	if err := rliBak.UnmarshalBinary(bs); err != nil || rliBak.Length() != 0 {
+		t.Errorf("Empty room list should be unmarshaled, get %d rooms and error %v.", rliBak.Length(), err)
+	}
+}
+
+// TestHeartbeatInfo ...
+func TestHeartbeatInfo(t *testing.T) {
+	msg, err := NewMessageFromInfoPkg(&HeartbeatInfo{})
+	if err != nil {
+		t.Error(err)
+	}
+	if mtype := msg.Type(); mtype != MsgHeartbeat {
+		t.Errorf("Type of message is wrong, hope %d, get %d.", MsgHeartbeat, mtype)
+	}
+
+	bs, _ := msg.MarshalBinary()
+	msg, err = NewMessageFromBytes(bs)
+	if err != nil {
+		t.Error(err)
+	}
+	ipkg, err := NewInfoPkgFromMsg(msg)
+	if err != nil {
+		t.Error(err)
+	}
+	if _, ok := ipkg.(*HeartbeatInfo); !ok {
+		t.Errorf("InfoPkg should be HeartbeatInfo, get %T.", ipkg)
+	}
+}
+
+// TestUnmarshalTruncatedInfos checks that truncated bodies are rejected by errors
+// instead of panics.
+func TestUnmarshalTruncatedInfos(t *testing.T) {
+	ipkgs := []InfoPkg{
+		&GameOverInfo{Overtype: 1},
+		&SpecialMsgInfo{Message: "special"},
+		&DisconnectInfo{UID: 1, RID: 2},
+		&ConnectedInfo{UID: 1, RID: 2, Name: "room"},
+		&ConnectInfo{UID: 1, RID: 2, Credential: "secret"},
+		&StatsQueryInfo{UID: 1, Target: 2},
+		&QuickPlayInfo{UID: 1, Region: "east", Skill: 3},
+		&StatsInfo{UID: 1, Matches: 2},
+		generateTestPlaygroundInfo(0, 2, 2, 2, 2),
+		&ChatInfo{UID: 1, Message: "hello"},
+		&PingInfo{Seq: 1, ServerTime: 2},
+		&PongInfo{Seq: 1},
+		&ClockSyncInfo{RTT: 1, Offset: 2},
+		&RoomListQueryInfo{UID: 1, Subscribe: true},
+		&RoomListInfo{Rooms: []*RoomSummary{{RID: 1, Name: "room"}}},
+	}
+
+	for _, ipkg := range ipkgs {
+		msg, err := NewMessageFromInfoPkg(ipkg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := msg.Body()
+
+		for n := 0; n < len(body); n++ {
+			truncated := NewMessage(msg.Type(), body[:n])
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Errorf("Unmarshal %T from %d bytes panics: %v.", ipkg, n, r)
+					}
+				}()
+				NewInfoPkgFromMsg(truncated)
+			}()
+		}
+
+		if _, err := NewInfoPkgFromMsg(NewMessage(msg.Type(), nil)); err == nil {
+			t.Errorf("Unmarshal %T from empty body should fail.", ipkg)
+		}
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"time"
 )
 
-var logger = b.Log
+var logger = b.Log.Named("message")
 
 // MsgType type for message
 type MsgType uint8
@@ -29,6 +29,14 @@ const (
 	MsgPlayground MsgType = 0x07
 	// MsgConnected is used when backend tell user has been connect.
 	MsgConnected MsgType = 0x06
+	// MsgStats is used when backend send lifetime stats of a player to frontend.
+	MsgStats MsgType = 0x0e
+	// MsgPing is used when backend measure the latency of frontend.
+	MsgPing MsgType = 0x10
+	// MsgClockSync is used when backend tell frontend the latency and clock offset.
+	MsgClockSync MsgType = 0x12
+	// MsgRoomList is used when backend send visible rooms to frontend.
+	MsgRoomList MsgType = 0x15
 
 	// frontend -> backend
 
@@ -39,6 +47,22 @@ const (
 	MsgConnect MsgType = 0x09
 	// MsgDisconnect is used when user want to leave game early.
 	MsgDisconnect MsgType = 0x08
+	// MsgStatsQuery is used when user want to get lifetime stats of a player.
+	MsgStatsQuery MsgType = 0x0d
+	// MsgPong is used when frontend reply MsgPing.
+	MsgPong MsgType = 0x11
+	// MsgHeartbeat is used when frontend keep connection alive without other messages.
+	MsgHeartbeat MsgType = 0x16
+	// MsgQuickPlay is used when user want to join a room chosen by backend.
+	MsgQuickPlay MsgType = 0x13
+	// MsgRoomListQuery is used when user want to get visible rooms.
+	MsgRoomListQuery MsgType = 0x14
+
+	// frontend <-> backend
+
+	// MsgChat is used when user send a chat message, and backend route it to
+	// other users.
+	MsgChat MsgType = 0x0f
 )
 
 const (
@@ -50,8 +74,40 @@ const (
 var (
 	// ErrInvalidMessage is the signature of invalid message.
 	ErrInvalidMessage = errors.New("Invalid message error")
+	// ErrMessageTooLarge is the signature of message larger than b.MessageMaxSize.
+	ErrMessageTooLarge = errors.New("Message is too large")
 )
 
+var msgTypeNames = map[MsgType]string{
+	MsgRandomUserID:   "RandomUserID",
+	MsgGameOver:       "GameOver",
+	MsgSpecialMessage: "SpecialMessage",
+	MsgPlayground:     "Playground",
+	MsgConnected:      "Connected",
+	MsgStats:          "Stats",
+	MsgPing:           "Ping",
+	MsgClockSync:      "ClockSync",
+	MsgRoomList:       "RoomList",
+	MsgUserSelf:       "UserSelf",
+	MsgConnect:        "Connect",
+	MsgDisconnect:     "Disconnect",
+	MsgStatsQuery:     "StatsQuery",
+	MsgPong:           "Pong",
+	MsgHeartbeat:      "Heartbeat",
+	MsgQuickPlay:      "QuickPlay",
+	MsgRoomListQuery:  "RoomListQuery",
+	MsgChat:           "Chat",
+}
+
+// String return the name of message type with its value, such as "Playground(0x07)".
+func (t MsgType) String() string {
+	name, ok := msgTypeNames[t]
+	if !ok {
+		name = "Unknown"
+	}
+	return fmt.Sprintf("%s(0x%02x)", name, uint8(t))
+}
+
 var infoMsgSendMap = map[InfoType]MsgType{
 	InfoGameOver:       MsgGameOver,
 	InfoSpecialMessage: MsgSpecialMessage,
@@ -59,6 +115,16 @@ var infoMsgSendMap = map[InfoType]MsgType{
 	InfoPlayground:     MsgPlayground,
 	InfoConnect:        MsgConnect,
 	InfoDisconnect:     MsgDisconnect,
+	InfoStats:          MsgStats,
+	InfoStatsQuery:     MsgStatsQuery,
+	InfoChat:           MsgChat,
+	InfoPing:           MsgPing,
+	InfoPong:           MsgPong,
+	InfoClockSync:      MsgClockSync,
+	InfoQuickPlay:      MsgQuickPlay,
+	InfoRoomList:       MsgRoomList,
+	InfoRoomListQuery:  MsgRoomListQuery,
+	InfoHeartbeat:      MsgHeartbeat,
 }
 
 // Message is the interface implemented by an object that can analyze base form of message
@@ -159,7 +225,13 @@ func (m *msg) MarshalBinary() ([]byte, error) {
 
 // UnmarshalBinary ...
 func (m *msg) UnmarshalBinary(bs []byte) error {
-	br := bufbo.NewBEBytesReader(bs)
+	if len(bs) > b.MessageMaxSize {
+		return ErrMessageTooLarge
+	}
+	if len(bs) < msgHeadSize {
+		return ErrInvalidMessage
+	}
+	br := bufbo.NewBECheckedReader(bs)
 
 	// first times checking message
 	length := int(br.Uint32())
@@ -171,7 +243,7 @@ func (m *msg) UnmarshalBinary(bs []byte) error {
 	m.t = MsgType(br.Uint8())
 	m.body = bs[msgHeadSize:]
 
-	return nil
+	return br.Err()
 }
 
 // NewRandomUserIDMsg create a new message whose type is MsgRandomUserID containing a randomID.
@@ -1,6 +1,7 @@
 package message
 
 import (
+	b "barrage-server/base"
 	"math"
 	"testing"
 	"time"
@@ -69,3 +70,45 @@ func TestMessageMarshalAndUnmarshal(t *testing.T) {
 		t.Errorf("Duration between new message and old message is wrong, duration: %v.", duration)
 	}
 }
+
+func TestMsgTypeString(t *testing.T) {
+	if s := MsgPlayground.String(); s != "Playground(0x07)" {
+		t.Errorf("Name of MsgPlayground is wrong, hope Playground(0x07), get %s.", s)
+	}
+	if s := MsgType(0xff).String(); s != "Unknown(0xff)" {
+		t.Errorf("Name of unknown type is wrong, hope Unknown(0xff), get %s.", s)
+	}
+}
+
+func TestMessageUnmarshalLimits(t *testing.T) {
+	if _, err := NewMessageFromBytes(make([]byte, msgHeadSize-1)); err != ErrInvalidMessage {
+		t.Errorf("Error of short message is wrong, hope %v, get %v.", ErrInvalidMessage, err)
+	}
+
+	bs, _ := NewMessage(MsgChat, make([]byte, 100)).MarshalBinary()
+	oldSize := b.MessageMaxSize
+	b.MessageMaxSize = 100
+	defer func() { b.MessageMaxSize = oldSize }()
+	if _, err := NewMessageFromBytes(bs); err != ErrMessageTooLarge {
+		t.Errorf("Error of large message is wrong, hope %v, get %v.", ErrMessageTooLarge, err)
+	}
+}
+
+func TestInfoStringLimit(t *testing.T) {
+	bs, _ := (&ChatInfo{UID: 1, Message: "hello"}).MarshalBinary()
+
+	oldLength := b.InfoStringMaxLength
+	b.InfoStringMaxLength = 4
+	defer func() { b.InfoStringMaxLength = oldLength }()
+
+	if err := new(ChatInfo).UnmarshalBinary(bs); err == nil {
+		t.Error("Chat message longer than InfoStringMaxLength should not be unmarshaled.")
+	}
+	// encoding and decoding agree on the limit.
+	if _, err := (&ChatInfo{UID: 1, Message: "hello"}).MarshalBinary(); err == nil {
+		t.Error("Chat message longer than InfoStringMaxLength should not be marshaled.")
+	}
+	if _, err := (&ConnectedInfo{UID: 1, RID: 1, Name: "hello"}).MarshalBinary(); err == nil {
+		t.Error("Room name longer than InfoStringMaxLength should not be marshaled.")
+	}
+}
@@ -37,15 +37,21 @@ func (dsi *DisappearsInfo) MarshalBinary() ([]byte, error) {
 
 // UnmarshalBinary ...
 func (dsi *DisappearsInfo) UnmarshalBinary(data []byte) error {
-	br := bufbo.NewBEBytesReader(data)
+	br := bufbo.NewBECheckedReader(data)
 
 	length := br.Uint32()
+	if err := br.Err(); err != nil {
+		return err
+	}
+	if err := checkListLength(length, disappearInfoSize, br.Remaining()); err != nil {
+		return err
+	}
 	dsi.IDs = make([]b.BallID, length)
 	for i := uint32(0); i < length; i++ {
 		dsi.IDs[i] = b.BallID(br.Uint16())
 	}
 
-	return nil
+	return br.Err()
 }
 
 // BallsInfo is used for ball informations transimission.
@@ -72,6 +78,11 @@ func (bsi *BallsInfo) Size() int {
 	return sum
 }
 
+// ItemMinSize return the size of ball without nickname.
+func (bsi *BallsInfo) ItemMinSize() int {
+	return ball.NewBall().Size()
+}
+
 // NewItems init BallInfos
 func (bsi *BallsInfo) NewItems(length uint32) {
 	bsi.BallInfos = make([]ball.Ball, length)
@@ -126,7 +137,7 @@ func (ci *CollisionInfo) UnmarshalBinary(data []byte) error {
 	ci.Damages = make([]b.Damage, 2)
 	ci.States = make([]ball.State, 2)
 
-	br := bufbo.NewBEBytesReader(data)
+	br := bufbo.NewBECheckedReader(data)
 	ci.IDs[0].UID = b.UserID(br.Uint32())
 	ci.IDs[0].ID = b.BallID(br.Uint16())
 	ci.IDs[1].UID = b.UserID(br.Uint32())
@@ -138,7 +149,7 @@ func (ci *CollisionInfo) UnmarshalBinary(data []byte) error {
 	ci.States[0] = ball.State(br.Uint8())
 	ci.States[1] = ball.State(br.Uint8())
 
-	return nil
+	return br.Err()
 }
 
 // CollisionsInfo is used for collision informations transimission.
@@ -165,6 +176,11 @@ func (csi *CollisionsInfo) Size() int {
 	return sum
 }
 
+// ItemMinSize return the size of CollisionInfo.
+func (csi *CollisionsInfo) ItemMinSize() int {
+	return collisionInfoSize
+}
+
 // NewItems init CollisionInfos
 func (csi *CollisionsInfo) NewItems(length uint32) {
 	csi.CollisionInfos = make([]*CollisionInfo, length)
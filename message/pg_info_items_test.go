@@ -169,3 +169,19 @@ func TestDisappearsInfoMarshallBinary(t *testing.T) {
 		t.Errorf("Value of items of marshaled binary should be %d, but get %d.", 99, v)
 	}
 }
+
+func TestDisappearsInfoUnmarshalBinaryLimits(t *testing.T) {
+	bs := generateDisappearInfoBytes(20)
+
+	dsi := new(DisappearsInfo)
+	if err := dsi.UnmarshalBinary(bs[:len(bs)-1]); err == nil {
+		t.Error("DisappearsInfo whose ids can't fit in bytes should not be unmarshaled.")
+	}
+
+	oldLimit := b.InfoListMaxLength
+	b.InfoListMaxLength = 19
+	defer func() { b.InfoListMaxLength = oldLimit }()
+	if err := dsi.UnmarshalBinary(bs); err == nil {
+		t.Error("DisappearsInfo longer than InfoListMaxLength should not be unmarshaled.")
+	}
+}
@@ -0,0 +1,108 @@
+package message
+
+import (
+	b "barrage-server/base"
+	"barrage-server/libs/bufbo"
+	"io"
+	"time"
+)
+
+// Encoder writes messages to a stream such as a connection or a replay file. messages
+// are written one by one in the form defined in protocal, so they are framed by their
+// length.
+type Encoder struct {
+	sw *bufbo.StreamWriter
+}
+
+// NewEncoder creates Encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{sw: bufbo.NewBEStreamWriter(w)}
+}
+
+// Encode writes msg and flushes it to the underlying writer, messages larger than
+// b.MessageMaxSize are not written because Decoder can't read them.
+func (e *Encoder) Encode(msg Message) error {
+	if msg.Size() > b.MessageMaxSize {
+		return ErrMessageTooLarge
+	}
+
+	e.sw.PutUint32(uint32(msg.Size()))
+	e.sw.PutFloat64(float64(msg.Timestamp().UnixNano()))
+	e.sw.PutUint8(uint8(msg.Type()))
+	e.sw.PutBytes(msg.Body())
+
+	return e.sw.Flush()
+}
+
+// EncodeInfoPkg writes the message created from ipkg.
+func (e *Encoder) EncodeInfoPkg(ipkg InfoPkg) error {
+	msg, err := NewMessageFromInfoPkg(ipkg)
+	if err != nil {
+		return err
+	}
+	return e.Encode(msg)
+}
+
+// Decoder reads messages written by Encoder from a stream.
+type Decoder struct {
+	sr  *bufbo.StreamReader
+	err error
+}
+
+// NewDecoder creates Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{sr: bufbo.NewBEStreamReader(r)}
+}
+
+// Decode reads the next message. it returns io.EOF if the stream is over between
+// messages, and io.ErrUnexpectedEOF if it is over in the middle of a message.
+//
+// the stream can't be read after an error, because the boundary of the next message
+// is lost, so Decode always returns the first error then.
+func (d *Decoder) Decode() (Message, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	m, err := d.decode()
+	if err != nil {
+		d.err = err
+		return nil, err
+	}
+	return m, nil
+}
+
+func (d *Decoder) decode() (*msg, error) {
+	length := int(d.sr.Uint32())
+	if err := d.sr.Err(); err != nil {
+		return nil, err
+	}
+	if length > b.MessageMaxSize {
+		return nil, ErrMessageTooLarge
+	}
+	if length < msgHeadSize {
+		return nil, ErrInvalidMessage
+	}
+
+	m := new(msg)
+	m.timestamp = time.Unix(0, int64(d.sr.Float64()))
+	m.t = MsgType(d.sr.Uint8())
+	m.body = d.sr.Bytes(length - msgHeadSize)
+
+	if err := d.sr.Err(); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+// DecodeInfoPkg reads the next message and creates InfoPkg from it.
+func (d *Decoder) DecodeInfoPkg() (InfoPkg, error) {
+	msg, err := d.Decode()
+	if err != nil {
+		return nil, err
+	}
+	return NewInfoPkgFromMsg(msg)
+}
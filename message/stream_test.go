@@ -0,0 +1,86 @@
+package message
+
+import (
+	b "barrage-server/base"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestEncoderAndDecoder(t *testing.T) {
+	var buffer bytes.Buffer
+	e := NewEncoder(&buffer)
+
+	m := generateTestMessage()
+	if err := e.Encode(m); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.EncodeInfoPkg(&ChatInfo{UID: 2333, Channel: 1, Message: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	bs, _ := m.MarshalBinary()
+	if !bytes.Equal(buffer.Bytes()[:len(bs)], bs) {
+		t.Errorf("Bytes of Encoder are wrong, hope % x, get % x.", bs, buffer.Bytes()[:len(bs)])
+	}
+
+	d := NewDecoder(&buffer)
+	dm, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dm.Type() != MsgConnect || !bytes.Equal(dm.Body(), m.Body()) {
+		t.Errorf("Decoded message is wrong, hope %v % x, get %v % x.", m.Type(), m.Body(), dm.Type(), dm.Body())
+	}
+	// timestamp is encoded as float64, so it may lose nanoseconds.
+	if diff := dm.Timestamp().Sub(m.Timestamp()); diff > time.Microsecond || diff < -time.Microsecond {
+		t.Errorf("Timestamp is wrong, hope %v, get %v.", m.Timestamp(), dm.Timestamp())
+	}
+
+	ipkg, err := d.DecodeInfoPkg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ci, ok := ipkg.(*ChatInfo); !ok || ci.Message != "hello" {
+		t.Errorf("Decoded info is wrong, get %+v.", ipkg)
+	}
+
+	if _, err := d.Decode(); err != io.EOF {
+		t.Errorf("Error at the end is wrong, hope %v, get %v.", io.EOF, err)
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	bs, _ := generateTestMessage().MarshalBinary()
+
+	d := NewDecoder(bytes.NewReader(bs[:len(bs)-1]))
+	if _, err := d.Decode(); err != io.ErrUnexpectedEOF {
+		t.Errorf("Error of cut message is wrong, hope %v, get %v.", io.ErrUnexpectedEOF, err)
+	}
+
+	d = NewDecoder(bytes.NewReader(bs[:4]))
+	if _, err := d.Decode(); err != io.ErrUnexpectedEOF {
+		t.Errorf("Error of message without head is wrong, hope %v, get %v.", io.ErrUnexpectedEOF, err)
+	}
+
+	d = NewDecoder(bytes.NewReader([]byte{0, 0, 0, 1, 0xff}))
+	if _, err := d.Decode(); err != ErrInvalidMessage {
+		t.Errorf("Error of short message is wrong, hope %v, get %v.", ErrInvalidMessage, err)
+	}
+	// errors are sticky.
+	if _, err := d.Decode(); err != ErrInvalidMessage {
+		t.Errorf("Error after failure is wrong, hope %v, get %v.", ErrInvalidMessage, err)
+	}
+
+	oldSize := b.MessageMaxSize
+	b.MessageMaxSize = 10
+	defer func() { b.MessageMaxSize = oldSize }()
+	if err := NewEncoder(&bytes.Buffer{}).Encode(generateTestMessage()); err != ErrMessageTooLarge {
+		t.Errorf("Error of encoding large message is wrong, hope %v, get %v.", ErrMessageTooLarge, err)
+	}
+	d = NewDecoder(bytes.NewReader(bs))
+	if _, err := d.Decode(); err != ErrMessageTooLarge {
+		t.Errorf("Error of large message is wrong, hope %v, get %v.", ErrMessageTooLarge, err)
+	}
+}
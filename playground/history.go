@@ -0,0 +1,106 @@
+package playground
+
+import (
+	b "barrage-server/base"
+	m "barrage-server/message"
+	"time"
+)
+
+// position is the location and radius of a ball at a moment.
+type position struct {
+	x, y, r uint16
+}
+
+// snapshot holds positions of all balls in playground at a tick.
+type snapshot struct {
+	at        time.Time
+	positions map[b.FullBallID]position
+}
+
+// positionHistory keeps snapshots no older than MaxRewind, the oldest snapshot is
+// at the beginning.
+type positionHistory struct {
+	snapshots []snapshot
+}
+
+// record take a snapshot of ballsGround at now, and drop snapshots which are too old.
+func (ph *positionHistory) record(now time.Time, ballsGround map[b.UserID]ballCache) {
+	positions := make(map[b.FullBallID]position)
+	for uid, bc := range ballsGround {
+		for id, bl := range bc {
+			x, y := bl.Position()
+			positions[b.FullBallID{UID: uid, ID: id}] = position{x: x, y: y, r: bl.Radius()}
+		}
+	}
+	ph.snapshots = append(ph.snapshots, snapshot{at: now, positions: positions})
+
+	expired := 0
+	for expired < len(ph.snapshots)-1 && now.Sub(ph.snapshots[expired+1].at) >= b.MaxRewind {
+		expired++
+	}
+	if expired > 0 {
+		ph.snapshots = append(ph.snapshots[:0], ph.snapshots[expired:]...)
+	}
+}
+
+// at return the latest snapshot not after t, if t is older than all snapshots, the
+// oldest one is returned. It returns nil if history is empty.
+func (ph *positionHistory) at(t time.Time) *snapshot {
+	if len(ph.snapshots) == 0 {
+		return nil
+	}
+
+	for i := len(ph.snapshots) - 1; i > 0; i-- {
+		if !ph.snapshots[i].at.After(t) {
+			return &ph.snapshots[i]
+		}
+	}
+	return &ph.snapshots[0]
+}
+
+// rewindTime clamp the time perceived by sender into the window of MaxRewind.
+func rewindTime(perceived, now time.Time) time.Time {
+	if perceived.IsZero() || perceived.After(now) {
+		return now
+	}
+	if earliest := now.Add(-b.MaxRewind); perceived.Before(earliest) {
+		return earliest
+	}
+	return perceived
+}
+
+// positionOf find position of ball fid, balls of sender are always at their current
+// location since sender controls them, balls of others are rewound to snap.
+func (pg *playground) positionOf(sender b.UserID, fid b.FullBallID, snap *snapshot) (position, bool) {
+	if fid.UID != sender && snap != nil {
+		if p, ok := snap.positions[fid]; ok {
+			return p, true
+		}
+	}
+
+	bl, ok := pg.ballsGround[fid.UID][fid.ID]
+	if !ok {
+		bl, ok = pg.userNewBallsCache[fid.UID][fid.ID]
+	}
+	if !ok {
+		return position{}, false
+	}
+	x, y := bl.Position()
+	return position{x: x, y: y, r: bl.Radius()}, true
+}
+
+// checkCollision check whether the two balls of ci could collide in the playground
+// perceived by sender at t. Collisions about unknown balls are not checked here.
+func (pg *playground) checkCollision(sender b.UserID, ci *m.CollisionInfo, t time.Time) bool {
+	snap := pg.history.at(rewindTime(t, time.Now()))
+
+	p1, ok1 := pg.positionOf(sender, ci.IDs[0], snap)
+	p2, ok2 := pg.positionOf(sender, ci.IDs[1], snap)
+	if !ok1 || !ok2 {
+		return true
+	}
+
+	dx, dy := int64(p1.x)-int64(p2.x), int64(p1.y)-int64(p2.y)
+	reach := int64(p1.r) + int64(p2.r) + int64(b.CollisionTolerance)
+	return dx*dx+dy*dy <= reach*reach
+}
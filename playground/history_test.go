@@ -0,0 +1,104 @@
+package playground
+
+import (
+	"barrage-server/ball"
+	b "barrage-server/base"
+	m "barrage-server/message"
+	tb "barrage-server/testLib/ball"
+	"testing"
+	"time"
+)
+
+// TestPositionHistory ...
+func TestPositionHistory(t *testing.T) {
+	var ph positionHistory
+	bg := map[b.UserID]ballCache{
+		1: {1: &tb.TestBall{Uid: 1, Id: 1, X: 10}},
+	}
+
+	start := time.Now()
+	ticks := int(b.MaxRewind/b.RoomBoardCastDuration) + 5
+	for i := 0; i < ticks; i++ {
+		bg[1][1].(*tb.TestBall).X = uint16(i)
+		ph.record(start.Add(time.Duration(i)*b.RoomBoardCastDuration), bg)
+	}
+
+	now := start.Add(time.Duration(ticks-1) * b.RoomBoardCastDuration)
+	if oldest := now.Sub(ph.snapshots[0].at); oldest > b.MaxRewind {
+		t.Errorf("Snapshots older than MaxRewind should be dropped, get %v.", oldest)
+	}
+
+	snap := ph.at(now.Add(-b.RoomBoardCastDuration - time.Millisecond))
+	if x := snap.positions[b.FullBallID{UID: 1, ID: 1}].x; x != uint16(ticks-3) {
+		t.Errorf("Position in snapshot is wrong, hope %d, get %d.", ticks-3, x)
+	}
+}
+
+// TestRewindTime ...
+func TestRewindTime(t *testing.T) {
+	now := time.Now()
+
+	if rt := rewindTime(time.Time{}, now); !rt.Equal(now) {
+		t.Errorf("Zero time should not be rewound, get %v.", rt)
+	}
+	if rt := rewindTime(now.Add(time.Second), now); !rt.Equal(now) {
+		t.Errorf("Future time should not be rewound, get %v.", rt)
+	}
+	if rt := rewindTime(now.Add(-time.Hour), now); !rt.Equal(now.Add(-b.MaxRewind)) {
+		t.Errorf("Rewind should be limited by MaxRewind, get %v.", now.Sub(rt))
+	}
+}
+
+// TestPlaygroundLagCompensation ...
+func TestPlaygroundLagCompensation(t *testing.T) {
+	pg := NewPlayground().(*playground)
+	pg.AddUser(1)
+	pg.AddUser(2)
+
+	bullet := &tb.TestBall{Uid: 1, Id: 1, X: 100, Y: 100, R: 5}
+	airplane := &tb.TestBall{Uid: 2, Id: 0, X: 110, Y: 100, R: 5}
+	pg.ballsGround[1][1] = bullet
+	pg.ballsGround[2][0] = airplane
+
+	// the airplane is near the bullet in the last tick, then it flies away.
+	pg.history.record(time.Now(), pg.ballsGround)
+	perceived := time.Now()
+	time.Sleep(time.Millisecond * 10)
+	pg.ballsGround[2][0] = &tb.TestBall{Uid: 2, Id: 0, X: 500, Y: 100, R: 5}
+	pg.history.record(time.Now(), pg.ballsGround)
+
+	newHit := func() *m.PlaygroundInfo {
+		pi := &m.PlaygroundInfo{
+			Sender:        1,
+			NewBalls:      &m.BallsInfo{},
+			Displacements: &m.BallsInfo{},
+			Disappears:    &m.DisappearsInfo{},
+			Collisions: &m.CollisionsInfo{
+				CollisionInfos: []*m.CollisionInfo{
+					{
+						IDs:     []b.FullBallID{{UID: 1, ID: 1}, {UID: 2, ID: 0}},
+						Damages: []b.Damage{0, 10},
+						States:  []ball.State{ball.Disappear, ball.Alive},
+					},
+				},
+			},
+		}
+		return pi
+	}
+
+	// the hit is refused in the current playground.
+	pi := newHit()
+	pi.Timestamp = time.Now()
+	pg.PutPkg(pi)
+	if cLen := pi.Collisions.Length(); cLen != 0 {
+		t.Errorf("Hit should be refused without rewinding, hope %d, get %d.", 0, cLen)
+	}
+
+	// the hit is accepted in the playground perceived by user 1.
+	pi = newHit()
+	pi.Timestamp = perceived
+	pg.PutPkg(pi)
+	if cLen := pi.Collisions.Length(); cLen != 1 {
+		t.Errorf("Hit should be accepted after rewinding, hope %d, get %d.", 1, cLen)
+	}
+}
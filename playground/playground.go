@@ -7,9 +7,10 @@ import (
 	"encoding/binary"
 	"errors"
 	"sync"
+	"time"
 )
 
-var logger = b.Log
+var logger = b.Log.Named("playground")
 
 const (
 	newBallIndex = iota
@@ -67,6 +68,8 @@ type Playground interface {
 	// package.
 	PkgsForEachUser() []*m.PlaygroundInfo
 	// cache and pack up the infos in playgroundInfo.
+	// collisions are checked in the playground rewound to pi.Timestamp, after calling,
+	// pi.Collisions only holds the collisions accepted by playground.
 	PutPkg(pi *m.PlaygroundInfo) error
 }
 
@@ -82,6 +85,9 @@ type playground struct {
 
 	// not concurrent secrity. only be used by fillPlaygroundInfo.
 	userBytesCache map[b.UserID][]bytesCache
+
+	// positions of balls in recent ticks, used to validate collisions of lagging users.
+	history positionHistory
 }
 
 // NewPlayground create default implement of Playground.
@@ -223,6 +229,9 @@ func (pg *playground) PkgsForEachUser() (pis []*m.PlaygroundInfo) {
 	// clean cache
 	pg.cleanCacheForEachUser()
 
+	// record positions of this tick
+	pg.history.record(time.Now(), pg.ballsGround)
+
 	return
 }
 
@@ -269,6 +278,10 @@ func (pg *playground) packUpPkgs(pi *m.PlaygroundInfo) error {
 	// collisionInfo, base check and cache them to userCollisionCache
 	validCollisionInfos := make([]*m.CollisionInfo, 0, pi.Collisions.Length())
 	for _, v := range pi.Collisions.CollisionInfos {
+		if !pg.checkCollision(uid, v, pi.Timestamp) {
+			continue
+		}
+
 		validCount := 2
 		if v.States[0] != ball.Alive {
 			if deleted := pg.checkAndDeleteBall(v.IDs[0].UID, v.IDs[0].ID); !deleted {
@@ -288,6 +301,7 @@ func (pg *playground) packUpPkgs(pi *m.PlaygroundInfo) error {
 	}
 
 	pg.userCollisionCache[uid] = append(pg.userCollisionCache[uid], validCollisionInfos...)
+	pi.Collisions.CollisionInfos = validCollisionInfos
 
 	// disappearInfos
 	for _, v := range pi.Disappears.IDs {
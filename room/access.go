@@ -0,0 +1,90 @@
+package room
+
+import (
+	"crypto/rand"
+	"sync"
+)
+
+// inviteCodeChars are the characters of generated invite codes, easily confused
+// characters like 'O' and '0' are excluded.
+const inviteCodeChars = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// inviteCodeLength is the length of generated invite codes.
+const inviteCodeLength = 6
+
+// roomAccess holds the visibility and credentials of a room. A room with password or
+// invite code can only be joined with one of them.
+type roomAccess struct {
+	accessM sync.RWMutex
+
+	private    bool
+	password   string
+	inviteCode string
+}
+
+// isPrivate ...
+func (ra *roomAccess) isPrivate() bool {
+	ra.accessM.RLock()
+	defer ra.accessM.RUnlock()
+
+	return ra.private
+}
+
+// check checks credential given by user.
+func (ra *roomAccess) check(credential string) error {
+	ra.accessM.RLock()
+	defer ra.accessM.RUnlock()
+
+	if ra.password == "" && ra.inviteCode == "" {
+		return nil
+	}
+	if credential == "" {
+		return errWrongCredential
+	}
+	if credential == ra.password || credential == ra.inviteCode {
+		return nil
+	}
+	return errWrongCredential
+}
+
+// SetPrivate hide room from room lists and quick play, empty password means room
+// can be joined without password.
+func (r *Room) SetPrivate(password string) {
+	r.access.accessM.Lock()
+	defer r.access.accessM.Unlock()
+
+	r.access.private = true
+	r.access.password = password
+}
+
+// SetPublic show room in room lists and quick play, and clear password and invite code.
+func (r *Room) SetPublic() {
+	r.access.accessM.Lock()
+	defer r.access.accessM.Unlock()
+
+	r.access.private = false
+	r.access.password = ""
+	r.access.inviteCode = ""
+}
+
+// NewInviteCode generate a new invite code for room, the former code is invalid.
+func (r *Room) NewInviteCode() (string, error) {
+	bs := make([]byte, inviteCodeLength)
+	if _, err := rand.Read(bs); err != nil {
+		return "", err
+	}
+	for i := range bs {
+		bs[i] = inviteCodeChars[int(bs[i])%len(inviteCodeChars)]
+	}
+
+	r.access.accessM.Lock()
+	defer r.access.accessM.Unlock()
+
+	r.access.inviteCode = string(bs)
+	return r.access.inviteCode, nil
+}
+
+// IsPrivate ...
+func (r *Room) IsPrivate() bool {
+	return r.access.isPrivate()
+}
@@ -0,0 +1,72 @@
+package room
+
+import (
+	m "barrage-server/message"
+	"testing"
+)
+
+// TestRoomAccess ...
+func TestRoomAccess(t *testing.T) {
+	r := NewRoom(20)
+	if err := r.access.check(""); err != nil {
+		t.Errorf("Public room should be joined without credential, get %v.", err)
+	}
+
+	r.SetPrivate("secret")
+	if !r.IsPrivate() {
+		t.Error("Room should be private.")
+	}
+	code, err := r.NewInviteCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != inviteCodeLength {
+		t.Errorf("Length of invite code is wrong, hope %d, get %d.", inviteCodeLength, len(code))
+	}
+
+	for _, credential := range []string{"secret", code} {
+		if err := r.access.check(credential); err != nil {
+			t.Errorf("Credential '%s' should be accepted, get %v.", credential, err)
+		}
+	}
+	for _, credential := range []string{"", "wrong"} {
+		if err := r.access.check(credential); err != errWrongCredential {
+			t.Errorf("Credential '%s' should be refused, hope %v, get %v.", credential, errWrongCredential, err)
+		}
+	}
+
+	// the former invite code is invalid.
+	if _, err := r.NewInviteCode(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.access.check(code); err != errWrongCredential {
+		t.Errorf("Former invite code should be refused, hope %v, get %v.", errWrongCredential, err)
+	}
+
+	r.SetPublic()
+	if r.IsPrivate() || r.access.check("") != nil {
+		t.Error("Room should be public and joined without credential.")
+	}
+}
+
+// TestHallJoinPrivateRoom ...
+func TestHallJoinPrivateRoom(t *testing.T) {
+	h := NewHall()
+	r := NewRoom(20)
+	r.SetPrivate("secret")
+	r.CompareAndSetStatus(roomClose, roomOpen)
+	h.rooms[20] = r
+
+	tu := &testUser{id: 1}
+	if err := h.joinRoom(tu, &m.ConnectInfo{UID: 1, RID: 20, Credential: "wrong"}); err != errWrongCredential {
+		t.Errorf("User should fail to join private room, hope %v, get %v.", errWrongCredential, err)
+	}
+	if err := h.joinRoom(tu, &m.ConnectInfo{UID: 1, RID: 20, Credential: "secret"}); err != nil {
+		t.Error(err)
+	}
+
+	// private room is never chosen by quick play.
+	if r.matchable("", 0) {
+		t.Error("Private room should not be matched.")
+	}
+}
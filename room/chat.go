@@ -0,0 +1,325 @@
+package room
+
+import (
+	b "barrage-server/base"
+	"barrage-server/libs/ratelimit"
+	m "barrage-server/message"
+	"barrage-server/user"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ChatFilter checks and filters chat messages before they are routed to users.
+type ChatFilter interface {
+	// Filter return the filtered message, if the message should be dropped, return false.
+	Filter(msg string) (string, bool)
+}
+
+// wordFilter replaces banned words in message with '*'.
+type wordFilter struct {
+	words []bannedWord
+}
+
+// bannedWord is a word of wordFilter and its number of runes.
+type bannedWord struct {
+	word  string
+	runes int
+}
+
+// NewWordFilter create a ChatFilter which masks words in message case-insensitively.
+func NewWordFilter(words []string) ChatFilter {
+	wf := &wordFilter{words: make([]bannedWord, 0, len(words))}
+	for _, w := range words {
+		if w != "" {
+			wf.words = append(wf.words, bannedWord{word: w, runes: utf8.RuneCountInString(w)})
+		}
+	}
+	return wf
+}
+
+// Filter masks every rune of banned words, words are compared rune by rune by
+// strings.EqualFold, so case folding never changes the position of a match.
+func (wf *wordFilter) Filter(msg string) (string, bool) {
+	var buf strings.Builder
+	buf.Grow(len(msg))
+
+	for i := 0; i < len(msg); {
+		if n, runes := wf.match(msg[i:]); n > 0 {
+			buf.WriteString(strings.Repeat("*", runes))
+			i += n
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(msg[i:])
+		buf.WriteString(msg[i : i+size])
+		i += size
+	}
+
+	return buf.String(), true
+}
+
+// match return the number of bytes and runes of the banned word at the beginning of s.
+func (wf *wordFilter) match(s string) (n int, runes int) {
+	for _, w := range wf.words {
+		n, runes = 0, 0
+		for runes < w.runes && n < len(s) {
+			_, size := utf8.DecodeRuneInString(s[n:])
+			n += size
+			runes++
+		}
+		if runes == w.runes && strings.EqualFold(s[:n], w.word) {
+			return n, runes
+		}
+	}
+	return 0, 0
+}
+
+// chatFilter is used by all rooms.
+var chatFilter = NewWordFilter(nil)
+
+// SetChatFilter replace the filter of chat messages, it should be called before
+// OpenGameHallAndRooms.
+func SetChatFilter(f ChatFilter) {
+	chatFilter = f
+}
+
+// chatLimiters keeps chat rate limiters of users, it is shared by all rooms so that
+// user can't reset the limit by leaving and rejoining room.
+type chatLimiters struct {
+	limitersM sync.Mutex
+
+	limiters  map[b.UserID]*chatLimiter
+	lastSweep time.Time
+}
+
+// chatLimiter is the token bucket of a user and the time it is used last.
+type chatLimiter struct {
+	bucket *ratelimit.Bucket
+	last   time.Time
+}
+
+// roomChatLimiters is used by all rooms.
+var roomChatLimiters = newChatLimiters()
+
+// newChatLimiters ...
+func newChatLimiters() *chatLimiters {
+	return &chatLimiters{
+		limiters:  make(map[b.UserID]*chatLimiter),
+		lastSweep: time.Now(),
+	}
+}
+
+// refillDuration is the time an unused bucket becomes full, then it is the same as
+// a new one and can be dropped.
+func refillDuration() time.Duration {
+	return time.Duration(float64(b.ChatBurst) / b.ChatRate * float64(time.Second))
+}
+
+// allow take a token from the bucket of user.
+func (cl *chatLimiters) allow(uid b.UserID) bool {
+	cl.limitersM.Lock()
+	defer cl.limitersM.Unlock()
+
+	now := time.Now()
+	expire := refillDuration()
+	if now.Sub(cl.lastSweep) > expire {
+		for id, l := range cl.limiters {
+			if now.Sub(l.last) > expire {
+				delete(cl.limiters, id)
+			}
+		}
+		cl.lastSweep = now
+	}
+
+	l, ok := cl.limiters[uid]
+	if !ok {
+		l = &chatLimiter{bucket: ratelimit.NewBucket(b.ChatRate, b.ChatBurst)}
+		cl.limiters[uid] = l
+	}
+	l.last = now
+	return l.bucket.Allow()
+}
+
+// chatMutes keeps the time mutes of users end, it is shared by all rooms so that
+// muted user can't escape by joining another room.
+type chatMutes struct {
+	mutesM sync.Mutex
+
+	mutes map[b.UserID]time.Time
+}
+
+// roomChatMutes is used by all rooms.
+var roomChatMutes = newChatMutes()
+
+// newChatMutes ...
+func newChatMutes() *chatMutes {
+	return &chatMutes{mutes: make(map[b.UserID]time.Time)}
+}
+
+// mute forbids user from chatting for d, ended mutes are dropped meanwhile.
+func (cm *chatMutes) mute(uid b.UserID, d time.Duration) {
+	cm.mutesM.Lock()
+	defer cm.mutesM.Unlock()
+
+	now := time.Now()
+	for id, until := range cm.mutes {
+		if !now.Before(until) {
+			delete(cm.mutes, id)
+		}
+	}
+	cm.mutes[uid] = now.Add(d)
+}
+
+// unmute ...
+func (cm *chatMutes) unmute(uid b.UserID) {
+	cm.mutesM.Lock()
+	defer cm.mutesM.Unlock()
+
+	delete(cm.mutes, uid)
+}
+
+// muted check whether user is muted now.
+func (cm *chatMutes) muted(uid b.UserID) bool {
+	cm.mutesM.Lock()
+	defer cm.mutesM.Unlock()
+
+	until, ok := cm.mutes[uid]
+	if !ok {
+		return false
+	}
+	if !time.Now().Before(until) {
+		delete(cm.mutes, uid)
+		return false
+	}
+	return true
+}
+
+// MuteUser forbids user from chatting in all rooms for d.
+func MuteUser(uid b.UserID, d time.Duration) {
+	roomChatMutes.mute(uid, d)
+}
+
+// UnmuteUser ...
+func UnmuteUser(uid b.UserID) {
+	roomChatMutes.unmute(uid)
+}
+
+// chatChannel keeps recent chat messages of a room.
+type chatChannel struct {
+	chatM sync.Mutex
+
+	history  []*m.ChatInfo
+	limiters *chatLimiters
+	mutes    *chatMutes
+}
+
+// newChatChannel ...
+func newChatChannel(limiters *chatLimiters, mutes *chatMutes) *chatChannel {
+	return &chatChannel{
+		history:  make([]*m.ChatInfo, 0, b.ChatHistorySize),
+		limiters: limiters,
+		mutes:    mutes,
+	}
+}
+
+// check checks whether user could send the chat message, then return the filtered message.
+func (cc *chatChannel) check(uid b.UserID, msg string) (string, error) {
+	if len(msg) > b.ChatMessageMaxLength {
+		return "", errChatTooLong
+	}
+
+	if cc.mutes.muted(uid) {
+		return "", errChatMuted
+	}
+
+	if !cc.limiters.allow(uid) {
+		return "", errChatTooFast
+	}
+
+	msg, ok := chatFilter.Filter(msg)
+	if !ok {
+		return "", errChatNotAllowed
+	}
+	return msg, nil
+}
+
+// record put ci into history, the oldest message will be dropped if history is full.
+func (cc *chatChannel) record(ci *m.ChatInfo) {
+	cc.chatM.Lock()
+	defer cc.chatM.Unlock()
+
+	if b.ChatHistorySize <= 0 {
+		return
+	}
+	if len(cc.history) >= b.ChatHistorySize {
+		cc.history = append(cc.history[:0], cc.history[len(cc.history)-b.ChatHistorySize+1:]...)
+	}
+	cc.history = append(cc.history, ci)
+}
+
+// recent return a copy of history.
+func (cc *chatChannel) recent() []*m.ChatInfo {
+	cc.chatM.Lock()
+	defer cc.chatM.Unlock()
+
+	history := make([]*m.ChatInfo, len(cc.history))
+	copy(history, cc.history)
+	return history
+}
+
+// Say send a chat message from server to all users in room.
+func (r *Room) Say(msg string) {
+	ci := &m.ChatInfo{UID: b.SysID, Channel: m.ChatRoom, Message: msg}
+	r.chat.record(ci)
+	r.routeChat(ci)
+}
+
+// handleChat checks chat message, then route it to users in room or team of sender.
+func (r *Room) handleChat(ci *m.ChatInfo) {
+	u, err := r.getUserSafely(ci.UID)
+	if err != nil {
+		logger.Errorf("Not find user %d in room %d. \n", ci.UID, r.id)
+		return
+	}
+
+	if ci.Channel != m.ChatRoom && ci.Channel != m.ChatTeam {
+		u.SendError(errChatChannel.Error())
+		return
+	}
+
+	msg, err := r.chat.check(ci.UID, ci.Message)
+	if err != nil {
+		u.SendError(err.Error())
+		return
+	}
+
+	routed := &m.ChatInfo{UID: ci.UID, Channel: ci.Channel, Message: msg}
+	if routed.Channel == m.ChatRoom {
+		r.chat.record(routed)
+	}
+	r.routeChat(routed)
+}
+
+// routeChat send chat message to users in room, team messages are only sent to the
+// team of sender.
+func (r *Room) routeChat(ci *m.ChatInfo) {
+	r.mapM.RLock()
+	defer r.mapM.RUnlock()
+
+	team := r.teams[ci.UID]
+	for uid, u := range r.users {
+		if ci.Channel == m.ChatTeam && r.teams[uid] != team {
+			continue
+		}
+		u.Send(ci)
+	}
+}
+
+// sendChatHistory send recent chat messages to u.
+func (r *Room) sendChatHistory(u user.User) {
+	for _, ci := range r.chat.recent() {
+		u.Send(ci)
+	}
+}
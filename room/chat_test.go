@@ -0,0 +1,143 @@
+package room
+
+import (
+	b "barrage-server/base"
+	m "barrage-server/message"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWordFilter ...
+func TestWordFilter(t *testing.T) {
+	wf := NewWordFilter([]string{"bad", "", "Noob"})
+
+	msg, ok := wf.Filter("You BAD noob, bad!")
+	if !ok {
+		t.Error("Word filter should not drop message.")
+	}
+	if hope := "You *** ****, ***!"; msg != hope {
+		t.Errorf("Filtered message is wrong, hope %s, get %s.", hope, msg)
+	}
+
+	// lower case of İ is longer than itself, words are still matched case-insensitively.
+	wf = NewWordFilter([]string{"bad", "straße"})
+	if msg, _ := wf.Filter("İ BAD STRAßE"); msg != "İ *** ******" {
+		t.Errorf("Filtered message is wrong, hope %s, get %s.", "İ *** ******", msg)
+	}
+}
+
+// TestChatChannelCheck ...
+func TestChatChannelCheck(t *testing.T) {
+	cc := newChatChannel(newChatLimiters(), newChatMutes())
+
+	if _, err := cc.check(1, strings.Repeat("a", b.ChatMessageMaxLength+1)); err != errChatTooLong {
+		t.Errorf("Too long message should be refused, hope %v, get %v.", errChatTooLong, err)
+	}
+
+	for i := 0; i < b.ChatBurst; i++ {
+		if _, err := cc.check(1, "hello"); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := cc.check(1, "hello"); err != errChatTooFast {
+		t.Errorf("Messages more than burst should be refused, hope %v, get %v.", errChatTooFast, err)
+	}
+
+	// limiters are shared by rooms, rejoining room doesn't reset the limit.
+	if _, err := newChatChannel(cc.limiters, cc.mutes).check(1, "hello"); err != errChatTooFast {
+		t.Errorf("Limit should be kept in another room, hope %v, get %v.", errChatTooFast, err)
+	}
+
+	cc.mutes.mute(2, time.Minute)
+	if _, err := cc.check(2, "hello"); err != errChatMuted {
+		t.Errorf("Muted user should be refused, hope %v, get %v.", errChatMuted, err)
+	}
+	// mutes are shared by rooms too.
+	if _, err := newChatChannel(cc.limiters, cc.mutes).check(2, "hello"); err != errChatMuted {
+		t.Errorf("Mute should be kept in another room, hope %v, get %v.", errChatMuted, err)
+	}
+	cc.mutes.unmute(2)
+	if _, err := cc.check(2, "hello"); err != nil {
+		t.Error(err)
+	}
+
+	// mute ends after the duration.
+	cc.mutes.mute(3, -time.Second)
+	if _, err := cc.check(3, "hello"); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestChatChannelHistory ...
+func TestChatChannelHistory(t *testing.T) {
+	cc := newChatChannel(newChatLimiters(), newChatMutes())
+
+	for i := 0; i < b.ChatHistorySize+5; i++ {
+		cc.record(&m.ChatInfo{UID: b.UserID(i)})
+	}
+
+	history := cc.recent()
+	if hLen := len(history); hLen != b.ChatHistorySize {
+		t.Errorf("Length of history is wrong, hope %d, get %d.", b.ChatHistorySize, hLen)
+	}
+	if uid := history[0].UID; uid != 5 {
+		t.Errorf("The oldest message should be dropped, hope uid %d, get %d.", 5, uid)
+	}
+	if uid := history[len(history)-1].UID; uid != b.UserID(b.ChatHistorySize+4) {
+		t.Errorf("The last message is wrong, hope uid %d, get %d.", b.ChatHistorySize+4, uid)
+	}
+}
+
+// TestRoomHandleChat ...
+func TestRoomHandleChat(t *testing.T) {
+	r := NewRoom(20)
+
+	received := make(map[b.UserID]int)
+	newCheckFunc := func(uid b.UserID) func(bs []byte, itype m.InfoType) {
+		return func(bs []byte, itype m.InfoType) {
+			if itype == m.InfoChat {
+				received[uid]++
+			}
+		}
+	}
+
+	tus := make([]*testUser, 4)
+	for i := range tus {
+		uid := b.UserID(i + 1)
+		tus[i] = &testUser{id: uid, checkFunc: newCheckFunc(uid)}
+		if err := r.UserJoin(tus[i]); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// users are put into two teams evenly.
+	if t1, t2 := r.teams[1], r.teams[2]; t1 == t2 {
+		t.Errorf("User 1 and user 2 should be in different teams, get %d and %d.", t1, t2)
+	}
+
+	r.handleChat(&m.ChatInfo{UID: 1, Channel: m.ChatRoom, Message: "hello"})
+	for _, tu := range tus {
+		if n := received[tu.id]; n != 1 {
+			t.Errorf("User %d should receive room message, hope %d, get %d.", tu.id, 1, n)
+		}
+	}
+
+	r.handleChat(&m.ChatInfo{UID: 1, Channel: m.ChatTeam, Message: "hello team"})
+	count := 0
+	for _, tu := range tus {
+		count += received[tu.id]
+	}
+	if count != 6 {
+		t.Errorf("Team message should only be sent to team members, hope %d, get %d.", 6, count)
+	}
+
+	// history is sent to the user who just joined room.
+	tu5 := &testUser{id: 5, checkFunc: newCheckFunc(5)}
+	if err := r.UserJoin(tu5); err != nil {
+		t.Error(err)
+	}
+	if n := received[5]; n != 1 {
+		t.Errorf("User 5 should receive chat history, hope %d, get %d.", 1, n)
+	}
+}
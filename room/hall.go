@@ -6,6 +6,7 @@ import (
 	"barrage-server/user"
 	"fmt"
 	"sync"
+	"time"
 )
 
 // Hall is a struct for user who is not playing game in room,
@@ -19,6 +20,13 @@ type Hall struct {
 	rooms map[b.RoomID]*Room
 	users map[b.UserID]user.User
 
+	// time of the last message from users in hall.
+	idle *idleTracker
+	// users subscribing the updates of rooms.
+	subscribers map[b.UserID]struct{}
+	// marshaled room list of the last push, only be used by pushRoomList.
+	lastRoomList []byte
+
 	infoChan chan m.InfoPkg
 	status   uint8
 }
@@ -28,7 +36,9 @@ func NewHall() (h *Hall) {
 	h = new(Hall)
 	h.rooms = make(map[b.RoomID]*Room)
 	h.users = make(map[b.UserID]user.User)
-	h.infoChan = make(chan m.InfoPkg, 10)
+	h.subscribers = make(map[b.UserID]struct{})
+	h.idle = newIdleTracker(0, b.HallIdleTimeout)
+	h.infoChan = make(chan m.InfoPkg, b.InfoChanSize)
 
 	return
 }
@@ -48,7 +58,8 @@ func (h *Hall) UserJoin(u user.User) error {
 		h.users[u.ID()] = u
 	}
 	// aways rebind room of user.
-	u.BindRoom(hallID, h.infoChan)
+	u.BindRoom(hallID, h.infoChan, 0)
+	h.idle.touch(u.ID(), time.Now())
 
 	return nil
 }
@@ -79,6 +90,20 @@ func (h *Hall) userLeftHall(uid b.UserID) {
 	defer h.uM.Unlock()
 
 	delete(h.users, uid)
+	delete(h.subscribers, uid)
+	h.idle.forget(uid)
+}
+
+// Users return all online users.
+func (h *Hall) Users() []user.User {
+	h.uM.RLock()
+	defer h.uM.RUnlock()
+
+	us := make([]user.User, 0, len(h.users))
+	for _, u := range h.users {
+		us = append(us, u)
+	}
+	return us
 }
 
 // InfoChan ...
@@ -107,6 +132,8 @@ func (h *Hall) handleConnect(ci *m.ConnectInfo) {
 			s = fmt.Sprintf("You have joined Room %d!", ci.RID)
 		case errRoomNotFound:
 			s = fmt.Sprintf("Room %d is not exist!", ci.RID)
+		case errWrongCredential:
+			s = fmt.Sprintf("Wrong password or invite code for Room %d!", ci.RID)
 		default:
 			logger.Errorln(err)
 			s = b.ErrServerError.Error()
@@ -115,6 +142,28 @@ func (h *Hall) handleConnect(ci *m.ConnectInfo) {
 	}
 }
 
+// handleStatsQuery ...
+func (h *Hall) handleStatsQuery(sqi *m.StatsQueryInfo) {
+	u, err := h.getUserSafely(sqi.UID)
+	if err != nil {
+		logger.Errorf("Not find user %d in hall. \n", sqi.UID)
+		return
+	}
+
+	sendStats(u, sqi.Target)
+}
+
+// handleChat refuse chat messages in hall, users should chat in room.
+func (h *Hall) handleChat(ci *m.ChatInfo) {
+	u, err := h.getUserSafely(ci.UID)
+	if err != nil {
+		logger.Errorf("Not find user %d in hall. \n", ci.UID)
+		return
+	}
+
+	u.SendError(errChatNotInRoom.Error())
+}
+
 // joinRoom ...
 func (h *Hall) joinRoom(u user.User, ci *m.ConnectInfo) error {
 	// filter wrong UID in receive message
@@ -125,6 +174,9 @@ func (h *Hall) joinRoom(u user.User, ci *m.ConnectInfo) error {
 	if !ok {
 		return errRoomNotFound
 	}
+	if err := r.access.check(ci.Credential); err != nil {
+		return err
+	}
 	return r.UserJoin(u)
 }
 
@@ -145,6 +197,10 @@ func (h *Hall) getUserSafely(uid b.UserID) (user.User, error) {
 func (h *Hall) HandleInfoPkg(ipkg m.InfoPkg) {
 	var err string
 
+	if uid, ok := senderOf(ipkg); ok {
+		h.idle.touch(uid, time.Now())
+	}
+
 	switch t := ipkg.Type(); t {
 	case m.InfoConnect:
 		ci, ok := ipkg.Body().(*m.ConnectInfo)
@@ -153,6 +209,34 @@ func (h *Hall) HandleInfoPkg(ipkg m.InfoPkg) {
 			break
 		}
 		h.handleConnect(ci)
+	case m.InfoQuickPlay:
+		qpi, ok := ipkg.Body().(*m.QuickPlayInfo)
+		if !ok {
+			err = "InfoPkg fails to be convert into QuickPlayInfo."
+			break
+		}
+		h.handleQuickPlay(qpi)
+	case m.InfoRoomListQuery:
+		rlqi, ok := ipkg.Body().(*m.RoomListQueryInfo)
+		if !ok {
+			err = "InfoPkg fails to be convert into RoomListQueryInfo."
+			break
+		}
+		h.handleRoomListQuery(rlqi)
+	case m.InfoStatsQuery:
+		sqi, ok := ipkg.Body().(*m.StatsQueryInfo)
+		if !ok {
+			err = "InfoPkg fails to be convert into StatsQueryInfo."
+			break
+		}
+		h.handleStatsQuery(sqi)
+	case m.InfoChat:
+		ci, ok := ipkg.Body().(*m.ChatInfo)
+		if !ok {
+			err = "InfoPkg fails to be convert into ChatInfo."
+			break
+		}
+		h.handleChat(ci)
 	default:
 		logger.Infof("Invalid information package! type: %d.\n", t)
 	}
@@ -162,9 +246,12 @@ func (h *Hall) HandleInfoPkg(ipkg m.InfoPkg) {
 	}
 }
 
-// LoopOperation ...
+// LoopOperation remove empty rooms created by quick play, push room list to
+// subscribers when rooms change, and disconnect idle users.
 func (h *Hall) LoopOperation() {
-
+	h.removeEmptyRooms()
+	h.pushRoomList()
+	h.checkIdleUsers()
 }
 
 // CompareAndSetStatus ...
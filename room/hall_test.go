@@ -103,7 +103,7 @@ func TestHallHandleInfoPkg(t *testing.T) {
 	count = 0
 
 	// full
-	for i := 0; i < rmLimit+1; i++ {
+	for i := 0; i < r.rooms[20].Settings().MembersLimit+1; i++ {
 		tu := &testUser{
 			id:        b.UserID(i + 2),
 			checkFunc: checkFunc,
@@ -0,0 +1,164 @@
+package room
+
+import (
+	b "barrage-server/base"
+	m "barrage-server/message"
+	"sync"
+	"time"
+)
+
+// idleCheckDuration is the duration between two idle checks.
+const idleCheckDuration = time.Second
+
+// idleTracker records the time of the last meaningful input of users.
+type idleTracker struct {
+	idleM sync.Mutex
+
+	lastInput map[b.UserID]time.Time
+	warned    map[b.UserID]bool
+	lastCheck time.Time
+
+	// warnAfter and evictAfter are copied at creation, a non-positive duration
+	// disables the corresponding check.
+	warnAfter  time.Duration
+	evictAfter time.Duration
+}
+
+// newIdleTracker ...
+func newIdleTracker(warnAfter, evictAfter time.Duration) *idleTracker {
+	return &idleTracker{
+		lastInput:  make(map[b.UserID]time.Time),
+		warned:     make(map[b.UserID]bool),
+		warnAfter:  warnAfter,
+		evictAfter: evictAfter,
+	}
+}
+
+// touch record an input of user at now.
+func (it *idleTracker) touch(uid b.UserID, now time.Time) {
+	it.idleM.Lock()
+	defer it.idleM.Unlock()
+
+	it.lastInput[uid] = now
+	delete(it.warned, uid)
+}
+
+// forget ...
+func (it *idleTracker) forget(uid b.UserID) {
+	it.idleM.Lock()
+	defer it.idleM.Unlock()
+
+	delete(it.lastInput, uid)
+	delete(it.warned, uid)
+}
+
+// shouldCheck return true at most once every idleCheckDuration.
+func (it *idleTracker) shouldCheck(now time.Time) bool {
+	it.idleM.Lock()
+	defer it.idleM.Unlock()
+
+	if now.Sub(it.lastCheck) < idleCheckDuration {
+		return false
+	}
+	it.lastCheck = now
+	return true
+}
+
+// due return users idle longer than warnAfter who are not warned yet, and users idle
+// longer than evictAfter. Warned users are marked, evicted users are forgotten.
+func (it *idleTracker) due(now time.Time) (warn, evict []b.UserID) {
+	it.idleM.Lock()
+	defer it.idleM.Unlock()
+
+	warnAfter, evictAfter := it.warnAfter, it.evictAfter
+	for uid, last := range it.lastInput {
+		idle := now.Sub(last)
+		if evictAfter > 0 && idle >= evictAfter {
+			evict = append(evict, uid)
+			delete(it.lastInput, uid)
+			delete(it.warned, uid)
+			continue
+		}
+		if warnAfter > 0 && idle >= warnAfter && !it.warned[uid] {
+			warn = append(warn, uid)
+			it.warned[uid] = true
+		}
+	}
+	return
+}
+
+// isMeaningful check whether pi carries any input of user.
+func isMeaningful(pi *m.PlaygroundInfo) bool {
+	return (pi.NewBalls != nil && pi.NewBalls.Length() > 0) ||
+		(pi.Displacements != nil && pi.Displacements.Length() > 0) ||
+		(pi.Collisions != nil && pi.Collisions.Length() > 0) ||
+		(pi.Disappears != nil && len(pi.Disappears.IDs) > 0)
+}
+
+// checkIdleUsers warn users idle longer than IdleWarnTimeout, and move users idle
+// longer than IdleEvictTimeout back to hall.
+func (r *Room) checkIdleUsers() {
+	now := time.Now()
+	if !r.idle.shouldCheck(now) {
+		return
+	}
+
+	warn, evict := r.idle.due(now)
+	for _, uid := range warn {
+		if u, err := r.getUserSafely(uid); err == nil {
+			u.SendError(errIdleWarning.Error())
+		}
+	}
+	for _, uid := range evict {
+		u, err := r.getUserSafely(uid)
+		if err != nil {
+			continue
+		}
+		if err := r.UserLeft(uid); err != nil {
+			logger.Errorln(err)
+			continue
+		}
+		logger.Infof("User %d is moved from room %d to hall for being idle. \n", uid, r.id)
+		u.SendError(errIdleEvicted.Error())
+	}
+}
+
+// senderOf return the user who sends ipkg to hall.
+func senderOf(ipkg m.InfoPkg) (b.UserID, bool) {
+	switch info := ipkg.Body().(type) {
+	case *m.ConnectInfo:
+		return info.UID, true
+	case *m.QuickPlayInfo:
+		return info.UID, true
+	case *m.StatsQueryInfo:
+		return info.UID, true
+	case *m.ChatInfo:
+		return info.UID, true
+	case *m.RoomListQueryInfo:
+		return info.UID, true
+	}
+	return 0, false
+}
+
+// checkIdleUsers disconnect users staying in hall without any message longer than
+// HallIdleTimeout, users playing in rooms are skipped.
+func (h *Hall) checkIdleUsers() {
+	now := time.Now()
+	if !h.idle.shouldCheck(now) {
+		return
+	}
+
+	_, evict := h.idle.due(now)
+	for _, uid := range evict {
+		u, err := h.getUserSafely(uid)
+		if err != nil {
+			continue
+		}
+		if u.Room() != hallID {
+			// user is playing, check it again after it returns to hall.
+			h.idle.touch(uid, now)
+			continue
+		}
+		u.Kick(errIdleDisconnected.Error())
+	}
+}
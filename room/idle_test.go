@@ -0,0 +1,101 @@
+package room
+
+import (
+	b "barrage-server/base"
+	m "barrage-server/message"
+	tm "barrage-server/testLib/message"
+	"testing"
+	"time"
+)
+
+// TestIdleTracker ...
+func TestIdleTracker(t *testing.T) {
+	it := newIdleTracker(time.Second*10, time.Second*30)
+	now := time.Now()
+	it.touch(1, now.Add(-time.Minute))
+	it.touch(2, now.Add(-time.Second*20))
+	it.touch(3, now)
+
+	warn, evict := it.due(now)
+	if len(warn) != 1 || warn[0] != 2 {
+		t.Errorf("User 2 should be warned, get %v.", warn)
+	}
+	if len(evict) != 1 || evict[0] != 1 {
+		t.Errorf("User 1 should be evicted, get %v.", evict)
+	}
+
+	// user is warned only once.
+	if warn, _ = it.due(now); len(warn) != 0 {
+		t.Errorf("User should be warned only once, get %v.", warn)
+	}
+
+	// input resets the warning.
+	it.touch(2, now.Add(-time.Second*20))
+	if warn, _ = it.due(now); len(warn) != 1 {
+		t.Errorf("User 2 should be warned again after input, get %v.", warn)
+	}
+
+	if !it.shouldCheck(now) || it.shouldCheck(now.Add(time.Millisecond)) {
+		t.Error("Idle check should be done at most once every idleCheckDuration.")
+	}
+}
+
+// TestRoomCheckIdleUsers ...
+func TestRoomCheckIdleUsers(t *testing.T) {
+	r := NewRoom(20)
+	r.idle.warnAfter, r.idle.evictAfter = time.Millisecond*20, time.Millisecond*50
+	messages := make(map[b.UserID][]string)
+	newTestUser := func(uid b.UserID) *testUser {
+		return &testUser{id: uid, checkFunc: func(bs []byte, itype m.InfoType) {
+			if itype == m.InfoSpecialMessage {
+				smi := new(m.SpecialMsgInfo)
+				smi.UnmarshalBinary(bs)
+				messages[uid] = append(messages[uid], smi.Message)
+			}
+		}}
+	}
+	r.UserJoin(newTestUser(1))
+	r.UserJoin(newTestUser(2))
+
+	time.Sleep(time.Millisecond * 30)
+	r.handlePlayground(tm.GenerateTestPlaygroundInfo(2, 0, 0, 0, 1))
+	r.idle.lastCheck = time.Time{}
+	r.checkIdleUsers()
+	if msgs := messages[1]; len(msgs) != 1 || msgs[0] != errIdleWarning.Error() {
+		t.Errorf("User 1 should be warned, get %v.", msgs)
+	}
+	if msgs := messages[2]; len(msgs) != 0 {
+		t.Errorf("User 2 should not be warned, get %v.", msgs)
+	}
+
+	time.Sleep(time.Millisecond * 30)
+	r.idle.lastCheck = time.Time{}
+	r.checkIdleUsers()
+	if _, err := r.getUserSafely(1); err != errUserNotFound {
+		t.Error("User 1 should be moved to hall.")
+	}
+	if _, err := r.getUserSafely(2); err != nil {
+		t.Error("User 2 should stay in room.")
+	}
+	LeftHall(1)
+}
+
+// TestHallCheckIdleUsers ...
+func TestHallCheckIdleUsers(t *testing.T) {
+	h := NewHall()
+	h.idle.evictAfter = time.Millisecond * 20
+	h.rooms[20] = NewRoom(20)
+	tu1, tu2 := &testUser{id: 1}, &testUser{id: 2}
+	h.UserJoin(tu1)
+	h.UserJoin(tu2)
+	h.rooms[20].UserJoin(tu2)
+
+	time.Sleep(time.Millisecond * 30)
+	h.checkIdleUsers()
+	if !tu1.kicked {
+		t.Error("Idle user in hall should be kicked.")
+	}
+	if tu2.kicked {
+		t.Error("User playing in room should not be kicked.")
+	}
+}
@@ -0,0 +1,92 @@
+package room
+
+import (
+	b "barrage-server/base"
+	m "barrage-server/message"
+	"bytes"
+	"sort"
+)
+
+// summary return the brief of room shown in lobby.
+func (r *Room) summary() *m.RoomSummary {
+	return &m.RoomSummary{
+		RID:    r.id,
+		Name:   r.settings.Name,
+		Count:  uint8(r.Count()),
+		Limit:  uint8(r.settings.MembersLimit),
+		Mode:   r.settings.Mode,
+		Status: r.Status(),
+	}
+}
+
+// roomList return summaries of all visible rooms sorted by id.
+func (h *Hall) roomList() *m.RoomListInfo {
+	h.rM.RLock()
+	defer h.rM.RUnlock()
+
+	rli := &m.RoomListInfo{Rooms: make([]*m.RoomSummary, 0, len(h.rooms))}
+	for _, r := range h.rooms {
+		if r.IsPrivate() {
+			continue
+		}
+		rli.Rooms = append(rli.Rooms, r.summary())
+	}
+	sort.Slice(rli.Rooms, func(i, j int) bool { return rli.Rooms[i].RID < rli.Rooms[j].RID })
+
+	return rli
+}
+
+// handleRoomListQuery send visible rooms to user, then subscribe or unsubscribe the
+// updates of rooms for user.
+func (h *Hall) handleRoomListQuery(rlqi *m.RoomListQueryInfo) {
+	u, err := h.getUserSafely(rlqi.UID)
+	if err != nil {
+		logger.Errorf("Not find user %d in hall. \n", rlqi.UID)
+		return
+	}
+
+	u.Send(h.roomList())
+
+	h.uM.Lock()
+	defer h.uM.Unlock()
+	if rlqi.Subscribe {
+		h.subscribers[rlqi.UID] = struct{}{}
+	} else {
+		delete(h.subscribers, rlqi.UID)
+	}
+}
+
+// pushRoomList send visible rooms to subscribers in hall if rooms changed since the
+// last push, subscribers playing in rooms are skipped.
+func (h *Hall) pushRoomList() {
+	rli := h.roomList()
+	bs, err := rli.MarshalBinary()
+	if err != nil {
+		logger.Errorln(err)
+		return
+	}
+	if bytes.Equal(bs, h.lastRoomList) {
+		return
+	}
+	h.lastRoomList = bs
+
+	h.uM.RLock()
+	defer h.uM.RUnlock()
+
+	for uid := range h.subscribers {
+		u, ok := h.users[uid]
+		if !ok || u.Room() != hallID {
+			continue
+		}
+		u.Send(rli)
+	}
+}
+
+// subscribed ...
+func (h *Hall) subscribed(uid b.UserID) bool {
+	h.uM.RLock()
+	defer h.uM.RUnlock()
+
+	_, ok := h.subscribers[uid]
+	return ok
+}
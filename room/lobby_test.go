@@ -0,0 +1,63 @@
+package room
+
+import (
+	b "barrage-server/base"
+	m "barrage-server/message"
+	"testing"
+)
+
+// TestHallRoomList ...
+func TestHallRoomList(t *testing.T) {
+	h := NewHall()
+	for _, rid := range []b.RoomID{3, 1, 2} {
+		h.rooms[rid] = NewRoom(rid)
+	}
+	h.rooms[2].SetPrivate("")
+	h.rooms[1].UserJoin(&testUser{id: 100})
+
+	received := 0
+	var rli *m.RoomListInfo
+	tu := &testUser{id: 1, checkFunc: func(bs []byte, itype m.InfoType) {
+		if itype == m.InfoRoomList {
+			received++
+			rli = new(m.RoomListInfo)
+			if err := rli.UnmarshalBinary(bs); err != nil {
+				t.Error(err)
+			}
+		}
+	}}
+	h.UserJoin(tu)
+
+	h.handleRoomListQuery(&m.RoomListQueryInfo{UID: 1, Subscribe: true})
+	if received != 1 {
+		t.Fatalf("User should receive room list, hope %d, get %d.", 1, received)
+	}
+	if l := rli.Length(); l != 2 {
+		t.Fatalf("Private room should be hidden, hope %d rooms, get %d.", 2, l)
+	}
+	if rs := rli.Rooms[0]; rs.RID != 1 || rs.Count != 1 || rs.Name != "Room 1" {
+		t.Errorf("Summary of room 1 is wrong, get %+v.", *rs)
+	}
+	if !h.subscribed(1) {
+		t.Error("User 1 should subscribe room list.")
+	}
+
+	// room list is pushed only when rooms change.
+	h.pushRoomList()
+	h.pushRoomList()
+	if received != 2 {
+		t.Errorf("Room list should be pushed once, hope %d, get %d.", 2, received)
+	}
+	h.rooms[3].UserJoin(&testUser{id: 101})
+	h.pushRoomList()
+	if received != 3 {
+		t.Errorf("Room list should be pushed after rooms change, hope %d, get %d.", 3, received)
+	}
+
+	h.handleRoomListQuery(&m.RoomListQueryInfo{UID: 1, Subscribe: false})
+	h.rooms[3].UserJoin(&testUser{id: 102})
+	h.pushRoomList()
+	if received != 4 {
+		t.Errorf("Room list should not be pushed after unsubscribing, hope %d, get %d.", 4, received)
+	}
+}
@@ -0,0 +1,209 @@
+package room
+
+import (
+	b "barrage-server/base"
+	m "barrage-server/message"
+	"barrage-server/user"
+	"math"
+)
+
+// Count return the number of users in room.
+func (r *Room) Count() int {
+	r.mapM.RLock()
+	defer r.mapM.RUnlock()
+
+	return len(r.users)
+}
+
+// rateUser record the skill of user in room, unrated users are not recorded.
+func (r *Room) rateUser(uid b.UserID, skill uint16) {
+	if skill == 0 {
+		return
+	}
+
+	r.mapM.Lock()
+	defer r.mapM.Unlock()
+
+	if _, ok := r.users[uid]; ok {
+		r.skills[uid] = skill
+	}
+}
+
+// averageSkill return the average skill of rated users in room, it returns false if
+// no user is rated.
+func (r *Room) averageSkill() (int, bool) {
+	r.mapM.RLock()
+	defer r.mapM.RUnlock()
+
+	if len(r.skills) == 0 {
+		return 0, false
+	}
+
+	sum := 0
+	for _, skill := range r.skills {
+		sum += int(skill)
+	}
+	return sum / len(r.skills), true
+}
+
+// userSkill return the skill rating of user computed from its lifetime stats, users
+// without finished matches are not rated. an even player is rated 1000, every kill
+// more than deaths per match adds 100.
+func userSkill(uid b.UserID) uint16 {
+	stats, err := statsStore.Stats(uid)
+	if err != nil || stats.Matches == 0 {
+		return 0
+	}
+
+	skill := 1000 + 100*(int(stats.Kills)-int(stats.Deaths))/int(stats.Matches)
+	if skill < 1 {
+		return 1
+	}
+	if skill > math.MaxUint16 {
+		return math.MaxUint16
+	}
+	return uint16(skill)
+}
+
+// validRegion check whether region is in b.MatchRegions, empty region is valid.
+func validRegion(region string) bool {
+	if region == "" {
+		return true
+	}
+	for _, r := range b.MatchRegions {
+		if r == region {
+			return true
+		}
+	}
+	return false
+}
+
+// matchable check whether user with skill could be matched into room in region.
+func (r *Room) matchable(region string, skill uint16) bool {
+	if r.Status() != roomOpen || r.IsPrivate() || r.Count() >= r.settings.MembersLimit {
+		return false
+	}
+	if region != "" && region != r.settings.Region {
+		return false
+	}
+	if skill == 0 {
+		return true
+	}
+
+	avg, ok := r.averageSkill()
+	if !ok {
+		return true
+	}
+	diff := avg - int(skill)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= b.MatchSkillRange
+}
+
+// handleQuickPlay join user into the room chosen by hall.
+func (h *Hall) handleQuickPlay(qpi *m.QuickPlayInfo) {
+	u, err := h.getUserSafely(qpi.UID)
+	if err != nil {
+		logger.Errorf("Not find user %d in hall. \n", qpi.UID)
+		return
+	}
+
+	if err := h.quickPlay(u, qpi); err != nil {
+		var s string
+		switch err {
+		case errNoRoomAvailable, errUserAlreadyJoin, errUnknownRegion:
+			s = err.Error()
+		default:
+			logger.Errorln(err)
+			s = b.ErrServerError.Error()
+		}
+		u.SendError(s)
+	}
+}
+
+// quickPlay join u into the least-full room matching qpi, if all rooms are full or
+// unmatched, a new room in the region of qpi is created. Room sends ConnectedInfo
+// back to user after joining.
+//
+// Skill of qpi is reported by client, so it is ignored, skill of user is computed
+// from its lifetime stats.
+func (h *Hall) quickPlay(u user.User, qpi *m.QuickPlayInfo) error {
+	if !validRegion(qpi.Region) {
+		return errUnknownRegion
+	}
+	skill := userSkill(qpi.UID)
+
+	h.rM.Lock()
+	defer h.rM.Unlock()
+
+	var chosen *Room
+	least := 0
+	for _, r := range h.rooms {
+		if !r.matchable(qpi.Region, skill) {
+			continue
+		}
+		count := r.Count()
+		if chosen == nil || count < least || (count == least && r.id < chosen.id) {
+			chosen, least = r, count
+		}
+	}
+
+	if chosen == nil {
+		r, err := h.createRoom(qpi.Region)
+		if err != nil {
+			return err
+		}
+		chosen = r
+	}
+
+	if err := chosen.UserJoin(u); err != nil {
+		return err
+	}
+	chosen.rateUser(qpi.UID, skill)
+	return nil
+}
+
+// createRoom create and open a temporary room with the smallest unused id, it should
+// be called with rM locked.
+func (h *Hall) createRoom(region string) (*Room, error) {
+	if len(h.rooms) >= b.MaxRooms {
+		return nil, errNoRoomAvailable
+	}
+
+	rid := b.RoomID(hallID + 1)
+	for {
+		if _, ok := h.rooms[rid]; !ok {
+			break
+		}
+		rid++
+	}
+
+	s := DefaultSettings(rid)
+	s.Region = region
+	r, err := NewRoomWithSettings(rid, s)
+	if err != nil {
+		return nil, err
+	}
+	r.temporary = true
+	h.rooms[rid] = r
+	Open(r, s.TickDuration)
+
+	logger.Infof("Room %d is created for region '%s'. \n", rid, region)
+	return r, nil
+}
+
+// removeEmptyRooms close and remove temporary rooms without users.
+func (h *Hall) removeEmptyRooms() {
+	h.rM.Lock()
+	defer h.rM.Unlock()
+
+	for rid, r := range h.rooms {
+		if !r.temporary || r.Count() > 0 {
+			continue
+		}
+		delete(h.rooms, rid)
+		Close(r)
+		logger.Infof("Empty room %d is removed. \n", rid)
+	}
+}
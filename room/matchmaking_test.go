@@ -0,0 +1,106 @@
+package room
+
+import (
+	b "barrage-server/base"
+	m "barrage-server/message"
+	"barrage-server/store"
+	"testing"
+)
+
+// TestHallQuickPlay ...
+func TestHallQuickPlay(t *testing.T) {
+	oldStore, oldRegions := statsStore, b.MatchRegions
+	defer func() { statsStore, b.MatchRegions = oldStore, oldRegions }()
+	statsStore = store.NewMemoryStore()
+	b.MatchRegions = []string{"asia", "eu"}
+
+	// user 1 is rated 1000, user 2 is rated 2000, skills reported by them are ignored.
+	statsStore.AddStats(1, store.PlayerStats{Matches: 1})
+	statsStore.AddStats(2, store.PlayerStats{Matches: 1, Kills: 10})
+
+	h := NewHall()
+	for _, rid := range []b.RoomID{1, 2} {
+		s := DefaultSettings(rid)
+		s.Region = "asia"
+		h.rooms[rid], _ = NewRoomWithSettings(rid, s)
+		h.rooms[rid].CompareAndSetStatus(roomClose, roomOpen)
+	}
+	defer func() {
+		for _, r := range h.rooms {
+			Close(r)
+		}
+	}()
+	h.rooms[1].UserJoin(&testUser{id: 100})
+	h.rooms[1].UserJoin(&testUser{id: 101})
+
+	var connected *m.ConnectedInfo
+	newTestUser := func(uid b.UserID) *testUser {
+		return &testUser{id: uid, checkFunc: func(bs []byte, itype m.InfoType) {
+			if itype == m.InfoConnected {
+				connected = new(m.ConnectedInfo)
+				connected.UnmarshalBinary(bs)
+			}
+		}}
+	}
+
+	// the least-full room in region is chosen.
+	if err := h.quickPlay(newTestUser(1), &m.QuickPlayInfo{UID: 1, Region: "asia", Skill: 2000}); err != nil {
+		t.Error(err)
+	}
+	if connected == nil || connected.RID != 2 {
+		t.Fatalf("User 1 should be matched into room 2, get %+v.", connected)
+	}
+
+	// room 2 doesn't match the skill of user 2.
+	connected = nil
+	if err := h.quickPlay(newTestUser(2), &m.QuickPlayInfo{UID: 2, Region: "asia"}); err != nil {
+		t.Error(err)
+	}
+	if connected == nil || connected.RID != 1 {
+		t.Fatalf("User 2 should be matched into room 1, get %+v.", connected)
+	}
+
+	// no room in region, a new room is created.
+	connected = nil
+	if err := h.quickPlay(newTestUser(3), &m.QuickPlayInfo{UID: 3, Region: "eu"}); err != nil {
+		t.Error(err)
+	}
+	if connected == nil || connected.RID != 3 {
+		t.Fatalf("User 3 should be matched into new room 3, get %+v.", connected)
+	}
+	if region := h.rooms[3].settings.Region; region != "eu" {
+		t.Errorf("Region of new room is wrong, hope %s, get %s.", "eu", region)
+	}
+
+	// unknown region is refused, no room is created for it.
+	if err := h.quickPlay(newTestUser(4), &m.QuickPlayInfo{UID: 4, Region: "moon"}); err != errUnknownRegion {
+		t.Errorf("Unknown region should be refused, hope %v, get %v.", errUnknownRegion, err)
+	}
+
+	// the created room is removed once empty, opened rooms are kept.
+	r3 := h.rooms[3]
+	r3.mapM.Lock()
+	delete(r3.users, 3)
+	r3.mapM.Unlock()
+	h.removeEmptyRooms()
+	if _, ok := h.rooms[3]; ok {
+		t.Error("Empty room created by quick play should be removed.")
+	}
+	if len(h.rooms) != 2 {
+		t.Errorf("Number of rooms is wrong, hope %d, get %d.", 2, len(h.rooms))
+	}
+}
+
+// TestHallCreateRoomLimit ...
+func TestHallCreateRoomLimit(t *testing.T) {
+	oldMaxRooms := b.MaxRooms
+	b.MaxRooms = 1
+	defer func() { b.MaxRooms = oldMaxRooms }()
+
+	h := NewHall()
+	h.rooms[1] = NewRoom(1)
+
+	if _, err := h.createRoom(""); err != errNoRoomAvailable {
+		t.Errorf("Room should not be created, hope %v, get %v.", errNoRoomAvailable, err)
+	}
+}
@@ -5,10 +5,11 @@ import (
 	m "barrage-server/message"
 	"barrage-server/user"
 	"errors"
+	"sort"
 	"time"
 )
 
-var logger = b.Log
+var logger = b.Log.Named("room")
 
 const (
 	// hallID id of hall
@@ -28,6 +29,22 @@ var (
 	errUserNotFound    = errors.New("User is not Found.")
 	errRoomIsFull      = errors.New("Room is full.")
 	errUserAlreadyJoin = errors.New("User already join.")
+	errNoRoomAvailable = errors.New("No room is available.")
+	errUnknownRegion   = errors.New("Unknown region.")
+	errWrongCredential = errors.New("Wrong password or invite code.")
+	errRoomClosed      = errors.New("Room is closed by server.")
+	errNotInHall       = errors.New("You should leave room first.")
+
+	errIdleWarning      = errors.New("You are idle and will be moved to hall soon.")
+	errIdleEvicted      = errors.New("You are moved to hall for being idle.")
+	errIdleDisconnected = errors.New("You are disconnected for being idle.")
+
+	errChatTooLong    = errors.New("Chat message is too long.")
+	errChatMuted      = errors.New("You are muted.")
+	errChatTooFast    = errors.New("Chat messages are sent too fast.")
+	errChatNotAllowed = errors.New("Chat message is not allowed.")
+	errChatChannel    = errors.New("Invalid chat channel.")
+	errChatNotInRoom  = errors.New("You should join a room before chatting.")
 )
 
 // CommonHall is the default entity of hall for all users.
@@ -36,11 +53,11 @@ var commonHall *Hall
 // OpenGameHallAndRooms init game hall and rooms, then open them.
 func OpenGameHallAndRooms(rids []b.RoomID) {
 	commonHall = NewHall()
-	Open(commonHall, time.Minute)
+	Open(commonHall, b.HallLoopDuration)
 
 	for _, rid := range rids {
 		commonHall.rooms[rid] = NewRoom(rid)
-		Open(commonHall.rooms[rid], b.RoomBoardCastDuration)
+		Open(commonHall.rooms[rid], commonHall.rooms[rid].settings.TickDuration)
 	}
 }
 
@@ -59,6 +76,96 @@ func LeftHall(userID b.UserID) {
 	}
 }
 
+// OnlineUsers return all users in common hall, including users playing in rooms.
+func OnlineUsers() []user.User {
+	if commonHall == nil {
+		return nil
+	}
+	return commonHall.Users()
+}
+
+// GetRoom return the room in common hall by id.
+func GetRoom(rid b.RoomID) (*Room, bool) {
+	if commonHall == nil {
+		return nil, false
+	}
+
+	commonHall.rM.RLock()
+	defer commonHall.rM.RUnlock()
+
+	r, ok := commonHall.rooms[rid]
+	return r, ok
+}
+
+// Rooms return all rooms in common hall sorted by id.
+func Rooms() []*Room {
+	if commonHall == nil {
+		return nil
+	}
+
+	commonHall.rM.RLock()
+	defer commonHall.rM.RUnlock()
+
+	rooms := make([]*Room, 0, len(commonHall.rooms))
+	for _, r := range commonHall.rooms {
+		rooms = append(rooms, r)
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].id < rooms[j].id })
+	return rooms
+}
+
+// KickUser disconnect an online user with reason.
+func KickUser(uid b.UserID, reason string) error {
+	if commonHall == nil {
+		return errUserNotFound
+	}
+
+	u, err := commonHall.getUserSafely(uid)
+	if err != nil {
+		return err
+	}
+	u.Kick(reason)
+	return nil
+}
+
+// Announce send a chat message from server to all online users.
+func Announce(msg string) {
+	ci := &m.ChatInfo{UID: b.SysID, Channel: m.ChatRoom, Message: msg}
+	for _, u := range OnlineUsers() {
+		u.Send(ci)
+	}
+}
+
+// CloseRoom close a room and remove it from common hall, users in the room are
+// moved back to hall.
+func CloseRoom(rid b.RoomID) error {
+	if commonHall == nil {
+		return errRoomNotFound
+	}
+
+	commonHall.rM.Lock()
+	r, ok := commonHall.rooms[rid]
+	delete(commonHall.rooms, rid)
+	commonHall.rM.Unlock()
+	if !ok {
+		return errRoomNotFound
+	}
+
+	Close(r)
+	for _, uid := range r.Users() {
+		u, err := r.getUserSafely(uid)
+		if err != nil {
+			continue
+		}
+		if err := r.UserLeft(uid); err != nil {
+			logger.Errorln(err)
+			continue
+		}
+		u.SendError(errRoomClosed.Error())
+	}
+	return nil
+}
+
 // Tiggler is a interface for Open and Close Room.
 type Tiggler interface {
 	// CompareAndSetStatus compare status of Tiggler with oldStatus, if oldStatus
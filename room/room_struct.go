@@ -2,13 +2,18 @@ package room
 
 import (
 	b "barrage-server/base"
+	"barrage-server/libs/log"
 	m "barrage-server/message"
 	pg "barrage-server/playground"
 	"barrage-server/user"
 	"sync"
+	"time"
 )
 
-var rmLimit = b.RoomMembersLimit
+const (
+	// teamCount is the number of teams in a room.
+	teamCount = 2
+)
 
 // Room marshal and cache infoes from playground sorting them by info sender.
 // When boardcast infoes from background, Room chooses and combines info bytes
@@ -18,8 +23,17 @@ type Room struct {
 	statusM sync.RWMutex
 
 	users      map[b.UserID]user.User
+	sessions   map[b.UserID]*session
+	teams      map[b.UserID]uint8
+	skills     map[b.UserID]uint16
+	chat       *chatChannel
+	idle       *idleTracker
 	playground pg.Playground
 	id         b.RoomID
+	settings   Settings
+	access     roomAccess
+	// temporary is true for rooms created by quick play, they are removed once empty.
+	temporary bool
 
 	//TODO: add infoChan for playground
 	infoChan chan m.InfoPkg
@@ -28,13 +42,30 @@ type Room struct {
 	status uint8
 }
 
-// NewRoom create a room struct using room id.
+// NewRoom create a room struct using room id and default settings.
 func NewRoom(id b.RoomID) (r *Room) {
+	r, _ = NewRoomWithSettings(id, DefaultSettings(id))
+	return
+}
+
+// NewRoomWithSettings create a room struct using room id and settings, settings should
+// be valid.
+func NewRoomWithSettings(id b.RoomID, s Settings) (r *Room, err error) {
+	if err = s.validate(); err != nil {
+		return nil, err
+	}
+
 	r = new(Room)
 	r.id = id
+	r.settings = s
 	r.users = make(map[b.UserID]user.User)
+	r.sessions = make(map[b.UserID]*session)
+	r.teams = make(map[b.UserID]uint8)
+	r.skills = make(map[b.UserID]uint16)
+	r.chat = newChatChannel(roomChatLimiters, roomChatMutes)
+	r.idle = newIdleTracker(b.IdleWarnTimeout, b.IdleEvictTimeout)
 	r.playground = pg.NewPlayground()
-	r.infoChan = make(chan m.InfoPkg, 10)
+	r.infoChan = make(chan m.InfoPkg, b.InfoChanSize)
 
 	return
 }
@@ -67,10 +98,10 @@ func (r *Room) UserJoin(u user.User) error {
 	uid := u.ID()
 
 	// send connected info back to front end.
-	ci := &m.ConnectedInfo{UID: uid, RID: r.id}
-	u.Send(ci)
+	u.Send(r.connectedInfo(uid))
+	r.sendChatHistory(u)
 
-	logger.Infof("User %d join room %d. \n", uid, r.id)
+	logger.With(log.Fields{"uid": uid, "rid": r.id}).Infof("User %d join room %d. \n", uid, r.id)
 
 	return nil
 }
@@ -80,7 +111,7 @@ func (r *Room) userJoin(u user.User) error {
 	r.mapM.Lock()
 	defer r.mapM.Unlock()
 
-	if len(r.users) >= rmLimit {
+	if len(r.users) >= r.settings.MembersLimit {
 		return errRoomIsFull
 	}
 
@@ -91,8 +122,11 @@ func (r *Room) userJoin(u user.User) error {
 	}
 
 	r.users[uid] = u
+	r.sessions[uid] = newSession(uid)
+	r.teams[uid] = r.smallestTeam()
+	r.idle.touch(uid, time.Now())
 	r.playground.AddUser(uid)
-	u.BindRoom(r.id, r.infoChan)
+	u.BindRoom(r.id, r.infoChan, r.settings.TickDuration)
 
 	return nil
 }
@@ -100,41 +134,110 @@ func (r *Room) userJoin(u user.User) error {
 // UserLeft ...
 func (r *Room) UserLeft(userID b.UserID) error {
 
-	if err := r.userLeft(userID); err != nil {
+	s, err := r.userLeft(userID)
+	if err != nil {
 		return err
 	}
 
-	logger.Infof("User %d left room %d. \n", userID, r.id)
+	saveSession(s)
+	logger.With(log.Fields{"uid": userID, "rid": r.id}).Infof("User %d left room %d. \n", userID, r.id)
 	return nil
 }
 
-// userLeft ...
-func (r *Room) userLeft(userID b.UserID) error {
+// userLeft remove user from room and return the session of the user.
+func (r *Room) userLeft(userID b.UserID) (*session, error) {
 	r.mapM.Lock()
 	defer r.mapM.Unlock()
 
 	u, ok := r.users[userID]
 	if !ok {
-		return errUserNotFound
+		return nil, errUserNotFound
 	}
 
 	JoinHall(u)
 	r.playground.DeleteUser(userID)
+	s := r.sessions[userID]
 	delete(r.users, userID)
+	delete(r.sessions, userID)
+	delete(r.teams, userID)
+	delete(r.skills, userID)
+	r.idle.forget(userID)
 
-	return nil
+	return s, nil
+}
+
+// smallestTeam return the team which has the fewest members, it should be called with mapM locked.
+func (r *Room) smallestTeam() uint8 {
+	var members [teamCount]int
+	for _, team := range r.teams {
+		members[team]++
+	}
+
+	smallest := uint8(0)
+	for team := uint8(1); team < teamCount; team++ {
+		if members[team] < members[smallest] {
+			smallest = team
+		}
+	}
+	return smallest
+}
+
+// getUserSafely ...
+func (r *Room) getUserSafely(uid b.UserID) (user.User, error) {
+	r.mapM.RLock()
+	defer r.mapM.RUnlock()
+
+	u, ok := r.users[uid]
+	if !ok {
+		return nil, errUserNotFound
+	}
+	return u, nil
 }
 
 // handlePlayground add playgroundInfo data into the cache of pi.Sender in room
 func (r *Room) handlePlayground(pi *m.PlaygroundInfo) {
+	r.filterFriendlyFire(pi.Collisions)
 	if err := r.playground.PutPkg(pi); err != nil {
 		if err == pg.ErrNotFoundUser {
 			logger.Errorf("Not find user %d in room cache map %d. \n", pi.Sender, r.id)
 		} else {
 			logger.Errorln(err)
 		}
+		return
+	}
+
+	if isMeaningful(pi) {
+		r.idle.touch(pi.Sender, time.Now())
+	}
+	r.recordCollisions(pi.Sender, pi.Collisions)
+}
+
+// recordCollisions record damages, kills and deaths of collisions accepted by playground
+// into sessions of users. both users of a collision report it, so a hit is only recorded
+// from the report of its attacker.
+func (r *Room) recordCollisions(sender b.UserID, csi *m.CollisionsInfo) {
+	r.mapM.Lock()
+	defer r.mapM.Unlock()
+
+	for _, ci := range csi.CollisionInfos {
+		if ci.IDs[0].UID == sender {
+			recordHit(r.sessions, ci.IDs[0], ci.IDs[1], ci.Damages[1], ci.States[1])
+		}
+		if ci.IDs[1].UID == sender {
+			recordHit(r.sessions, ci.IDs[1], ci.IDs[0], ci.Damages[0], ci.States[0])
+		}
+	}
+}
+
+// handleStatsQuery ...
+func (r *Room) handleStatsQuery(sqi *m.StatsQueryInfo) {
+	u, err := r.getUserSafely(sqi.UID)
+	if err != nil {
+		logger.Errorf("Not find user %d in room %d. \n", sqi.UID, r.id)
+		return
 	}
 
+	sendStats(u, sqi.Target)
 }
 
 // handleDisconnect ...
@@ -174,9 +277,10 @@ func (r *Room) InfoChan() <-chan m.InfoPkg {
 	return r.infoChan
 }
 
-// LoopOperation wrap playgroundBoardCast.
+// LoopOperation wrap playgroundBoardCast and checkIdleUsers.
 func (r *Room) LoopOperation() {
 	r.playgroundBoardCast()
+	r.checkIdleUsers()
 }
 
 // HandleInfoPkg ...
@@ -198,12 +302,31 @@ func (r *Room) HandleInfoPkg(ipkg m.InfoPkg) {
 			break
 		}
 		r.handleDisconnect(dsi)
+	case m.InfoStatsQuery:
+		sqi, ok := ipkg.Body().(*m.StatsQueryInfo)
+		if !ok {
+			err = "InfoPkg fails to be convert into StatsQueryInfo."
+			break
+		}
+		r.handleStatsQuery(sqi)
+	case m.InfoChat:
+		ci, ok := ipkg.Body().(*m.ChatInfo)
+		if !ok {
+			err = "InfoPkg fails to be convert into ChatInfo."
+			break
+		}
+		r.handleChat(ci)
+	case m.InfoQuickPlay, m.InfoRoomListQuery:
+		// they are handled by hall, user should leave room first.
+		if uid, ok := senderOf(ipkg); ok {
+			r.replyError(uid, errNotInHall)
+		}
 
 	// flowing two type is unusable now.
 	case m.InfoAirplaneCreated:
 	case m.InfoSpecialMessage:
 	default:
-		logger.Errorf("Invalid information package! type: %d.\n", t)
+		logger.With(log.Fields{"rid": r.id, "type": t}).Infof("Invalid information package! type: %d.\n", t)
 	}
 
 	if err != "" {
@@ -211,6 +334,16 @@ func (r *Room) HandleInfoPkg(ipkg m.InfoPkg) {
 	}
 }
 
+// replyError send err to user in room.
+func (r *Room) replyError(uid b.UserID, err error) {
+	u, e := r.getUserSafely(uid)
+	if e != nil {
+		logger.Infof("Not find user %d in room %d. \n", uid, r.id)
+		return
+	}
+	u.SendError(err.Error())
+}
+
 // Status check whether room is close.
 //
 // Before user sending infopkg into infoChan, they should call this fucntion to check
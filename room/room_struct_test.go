@@ -4,6 +4,7 @@ import (
 	b "barrage-server/base"
 	m "barrage-server/message"
 	tm "barrage-server/testLib/message"
+	"barrage-server/user"
 	"testing"
 	"time"
 )
@@ -107,6 +108,7 @@ type testUser struct {
 
 	infopkgChan chan<- m.InfoPkg
 	checkFunc   func(bs []byte, itype m.InfoType)
+	kicked      bool
 }
 
 // Play ...
@@ -151,11 +153,22 @@ func (tu *testUser) UploadInfo(infopkg m.InfoPkg) error {
 }
 
 // BindRoom ...
-func (tu *testUser) BindRoom(id b.RoomID, c chan<- m.InfoPkg) {
+func (tu *testUser) BindRoom(id b.RoomID, c chan<- m.InfoPkg, tick time.Duration) {
 	tu.rid = id
 	tu.infopkgChan = c
 }
 
+// Latency ...
+func (tu *testUser) Latency() user.Latency {
+	return user.Latency{}
+}
+
+// Kick ...
+func (tu *testUser) Kick(reason string) {
+	tu.kicked = true
+	tu.SendError(reason)
+}
+
 // TestRoomUserJoinAndLeft ...
 func TestRoomUserJoinAndLeftAndIDAndUsers(t *testing.T) {
 	r := NewRoom(20)
@@ -306,3 +319,22 @@ func TestRoomHandlePlaygroundInfoAndPlaygroundBoardCast(t *testing.T) {
 
 	Close(r)
 }
+
+// TestRoomHandleHallInfo ...
+func TestRoomHandleHallInfo(t *testing.T) {
+	r := NewRoom(20)
+	var messages []string
+	r.UserJoin(&testUser{id: 1, checkFunc: func(bs []byte, itype m.InfoType) {
+		if itype == m.InfoSpecialMessage {
+			smi := new(m.SpecialMsgInfo)
+			smi.UnmarshalBinary(bs)
+			messages = append(messages, smi.Message)
+		}
+	}})
+
+	r.HandleInfoPkg(&m.QuickPlayInfo{UID: 1})
+	r.HandleInfoPkg(&m.RoomListQueryInfo{UID: 1})
+	if len(messages) != 2 || messages[0] != errNotInHall.Error() || messages[1] != errNotInHall.Error() {
+		t.Errorf("User should be told to leave room first, get %v.", messages)
+	}
+}
@@ -0,0 +1,111 @@
+package room
+
+import (
+	b "barrage-server/base"
+	m "barrage-server/message"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var errInvalidSettings = errors.New("Invalid settings of room.")
+
+// Settings is the configuration of a room, it is set at the creation of room and
+// never changed.
+type Settings struct {
+	Name   string
+	Region string
+
+	MembersLimit int
+	Width        int
+	Height       int
+	// TickDuration is the duration between two boardcast of the room.
+	TickDuration time.Duration
+	Mode         b.GameMode
+	// FriendlyFire is whether users in the same team can hurt each other, it only
+	// works in TeamBattle mode.
+	FriendlyFire bool
+	Background   b.ImageID
+}
+
+// DefaultSettings return settings from the global parameters in base.
+func DefaultSettings(id b.RoomID) Settings {
+	return Settings{
+		Name:         fmt.Sprintf("Room %d", id),
+		MembersLimit: b.RoomMembersLimit,
+		Width:        b.PlayGroundWidth,
+		Height:       b.PlayGroundHeight,
+		TickDuration: b.RoomBoardCastDuration,
+		Mode:         b.RoomGameMode,
+		FriendlyFire: b.RoomFriendlyFire,
+		Background:   b.RoomBackground,
+	}
+}
+
+// validate checks whether settings could be sent to frontend in ConnectedInfo.
+func (s *Settings) validate() error {
+	if s.MembersLimit <= 0 || s.MembersLimit > math.MaxUint8 {
+		return errInvalidSettings
+	}
+	if s.Width <= 0 || s.Width > math.MaxUint16 || s.Height <= 0 || s.Height > math.MaxUint16 {
+		return errInvalidSettings
+	}
+	if s.TickDuration < time.Millisecond || s.TickDuration > math.MaxUint16*time.Millisecond {
+		return errInvalidSettings
+	}
+	if s.Mode != b.FreeForAll && s.Mode != b.TeamBattle {
+		return errInvalidSettings
+	}
+	if len(s.Name) > b.InfoStringMaxLength {
+		return errInvalidSettings
+	}
+	return nil
+}
+
+// Settings ...
+func (r *Room) Settings() Settings {
+	return r.settings
+}
+
+// connectedInfo construct ConnectedInfo with settings of room for user uid.
+func (r *Room) connectedInfo(uid b.UserID) *m.ConnectedInfo {
+	s := &r.settings
+	return &m.ConnectedInfo{
+		UID:          uid,
+		RID:          r.id,
+		MembersLimit: uint8(s.MembersLimit),
+		Width:        uint16(s.Width),
+		Height:       uint16(s.Height),
+		TickDuration: uint16(s.TickDuration / time.Millisecond),
+		Mode:         s.Mode,
+		FriendlyFire: s.FriendlyFire,
+		Background:   s.Background,
+		Name:         s.Name,
+	}
+}
+
+// filterFriendlyFire drop collisions between balls of different users in the same
+// team, if friendly fire is off in TeamBattle mode.
+func (r *Room) filterFriendlyFire(csi *m.CollisionsInfo) {
+	if csi == nil || r.settings.Mode != b.TeamBattle || r.settings.FriendlyFire {
+		return
+	}
+
+	r.mapM.RLock()
+	defer r.mapM.RUnlock()
+
+	valid := csi.CollisionInfos[:0]
+	for _, ci := range csi.CollisionInfos {
+		uid1, uid2 := ci.IDs[0].UID, ci.IDs[1].UID
+		if uid1 != uid2 {
+			t1, ok1 := r.teams[uid1]
+			t2, ok2 := r.teams[uid2]
+			if ok1 && ok2 && t1 == t2 {
+				continue
+			}
+		}
+		valid = append(valid, ci)
+	}
+	csi.CollisionInfos = valid
+}
@@ -0,0 +1,91 @@
+package room
+
+import (
+	"barrage-server/ball"
+	b "barrage-server/base"
+	m "barrage-server/message"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNewRoomWithSettings ...
+func TestNewRoomWithSettings(t *testing.T) {
+	s := DefaultSettings(20)
+	s.MembersLimit = 0
+	if _, err := NewRoomWithSettings(20, s); err != errInvalidSettings {
+		t.Errorf("Room with invalid settings should not be created, hope %v, get %v.", errInvalidSettings, err)
+	}
+
+	// name of room should be decoded by clients.
+	s = DefaultSettings(20)
+	s.Name = strings.Repeat("a", b.InfoStringMaxLength+1)
+	if _, err := NewRoomWithSettings(20, s); err != errInvalidSettings {
+		t.Errorf("Room with too long name should not be created, hope %v, get %v.", errInvalidSettings, err)
+	}
+
+	s = DefaultSettings(20)
+	s.MembersLimit = 1
+	s.TickDuration = time.Millisecond * 50
+	s.Mode = b.TeamBattle
+	r, err := NewRoomWithSettings(20, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var connected *m.ConnectedInfo
+	tu := &testUser{id: 1, checkFunc: func(bs []byte, itype m.InfoType) {
+		if itype == m.InfoConnected {
+			connected = new(m.ConnectedInfo)
+			connected.UnmarshalBinary(bs)
+		}
+	}}
+	if err := r.UserJoin(tu); err != nil {
+		t.Error(err)
+	}
+	if connected == nil {
+		t.Fatal("User should receive ConnectedInfo.")
+	}
+	if connected.MembersLimit != 1 || connected.TickDuration != 50 || connected.Mode != b.TeamBattle ||
+		connected.Name != "Room 20" {
+		t.Errorf("Settings in ConnectedInfo are wrong, get %+v.", *connected)
+	}
+
+	// members limit of the room is used.
+	if err := r.UserJoin(&testUser{id: 2}); err != errRoomIsFull {
+		t.Errorf("Room should be full, hope %v, get %v.", errRoomIsFull, err)
+	}
+}
+
+// TestRoomFilterFriendlyFire ...
+func TestRoomFilterFriendlyFire(t *testing.T) {
+	s := DefaultSettings(20)
+	s.Mode = b.TeamBattle
+	r, _ := NewRoomWithSettings(20, s)
+	for uid := b.UserID(1); uid <= 3; uid++ {
+		r.UserJoin(&testUser{id: uid})
+	}
+	// user 1 and user 3 are in the same team.
+	newCollision := func(uid1, uid2 b.UserID) *m.CollisionInfo {
+		return &m.CollisionInfo{
+			IDs:     []b.FullBallID{{UID: uid1, ID: 1}, {UID: uid2, ID: 0}},
+			Damages: []b.Damage{0, 10},
+			States:  []ball.State{ball.Disappear, ball.Alive},
+		}
+	}
+	csi := &m.CollisionsInfo{CollisionInfos: []*m.CollisionInfo{
+		newCollision(1, 2), newCollision(1, 3), newCollision(1, 1),
+	}}
+
+	r.filterFriendlyFire(csi)
+	if cLen := csi.Length(); cLen != 2 {
+		t.Errorf("Collision between teammates should be dropped, hope %d, get %d.", 2, cLen)
+	}
+
+	r.settings.FriendlyFire = true
+	csi.CollisionInfos = append(csi.CollisionInfos, newCollision(1, 3))
+	r.filterFriendlyFire(csi)
+	if cLen := csi.Length(); cLen != 3 {
+		t.Errorf("Collision between teammates should be kept with friendly fire, hope %d, get %d.", 3, cLen)
+	}
+}
@@ -0,0 +1,97 @@
+package room
+
+import (
+	"barrage-server/ball"
+	b "barrage-server/base"
+	m "barrage-server/message"
+	"barrage-server/store"
+	"barrage-server/user"
+	"time"
+)
+
+const (
+	// airplaneID is the ball id of user's airplane.
+	airplaneID = b.BallID(0)
+)
+
+// statsStore keeps lifetime stats of players, the stats of a user is put into it
+// when the user lefts room.
+var statsStore = store.NewMemoryStore()
+
+// SetStatsStore replace the store of player stats, it should be called before
+// OpenGameHallAndRooms.
+func SetStatsStore(s store.Store) {
+	statsStore = s
+}
+
+// StatsStore return the store of player stats.
+func StatsStore() store.Store {
+	return statsStore
+}
+
+// session records the stats of a user from the user joining room.
+type session struct {
+	joinAt time.Time
+	stats  store.PlayerStats
+}
+
+// newSession ...
+func newSession(uid b.UserID) *session {
+	return &session{
+		joinAt: time.Now(),
+		stats:  store.PlayerStats{UID: uid},
+	}
+}
+
+// saveSession put the stats of s into statsStore as a finished match.
+func saveSession(s *session) {
+	s.stats.Matches = 1
+	s.stats.TimePlayed = time.Since(s.joinAt)
+
+	if err := statsStore.AddStats(s.stats.UID, s.stats); err != nil {
+		logger.Errorf("Failed to save stats of user %d: %v.\n", s.stats.UID, err)
+	}
+}
+
+// recordHit record the damage from the ball of attacker to the ball of victim into
+// sessions, if victim is an airplane and killed, a kill and a death are also recorded.
+func recordHit(sessions map[b.UserID]*session, attacker, victim b.FullBallID,
+	damage b.Damage, victimState ball.State) {
+	if attacker.UID == victim.UID {
+		return
+	}
+
+	as, ok := sessions[attacker.UID]
+	if ok {
+		as.stats.Damage += uint64(damage)
+	}
+
+	if victimState != ball.Dead || victim.ID != airplaneID {
+		return
+	}
+	if ok {
+		as.stats.Kills++
+	}
+	if vs, ok := sessions[victim.UID]; ok {
+		vs.stats.Deaths++
+	}
+}
+
+// sendStats send the lifetime stats of target to u.
+func sendStats(u user.User, target b.UserID) {
+	ps, err := statsStore.Stats(target)
+	if err != nil {
+		logger.Errorln(err)
+		u.SendError(b.ErrServerError.Error())
+		return
+	}
+
+	u.Send(&m.StatsInfo{
+		UID:        ps.UID,
+		Matches:    ps.Matches,
+		Kills:      ps.Kills,
+		Deaths:     ps.Deaths,
+		Damage:     ps.Damage,
+		TimePlayed: uint32(ps.TimePlayed / time.Second),
+	})
+}
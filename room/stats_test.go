@@ -0,0 +1,104 @@
+package room
+
+import (
+	"barrage-server/ball"
+	b "barrage-server/base"
+	m "barrage-server/message"
+	"barrage-server/store"
+	"testing"
+)
+
+// TestRecordHit ...
+func TestRecordHit(t *testing.T) {
+	sessions := map[b.UserID]*session{
+		1: newSession(1),
+		2: newSession(2),
+	}
+	bullet := b.FullBallID{UID: 1, ID: 10}
+	airplane := b.FullBallID{UID: 2, ID: airplaneID}
+
+	recordHit(sessions, bullet, airplane, 30, ball.Alive)
+	recordHit(sessions, bullet, airplane, 20, ball.Dead)
+	// hitting self should be ignored.
+	recordHit(sessions, bullet, b.FullBallID{UID: 1, ID: airplaneID}, 20, ball.Dead)
+
+	if damage := sessions[1].stats.Damage; damage != 50 {
+		t.Errorf("Damage of user 1 is wrong, hope %d, get %d.", 50, damage)
+	}
+	if kills := sessions[1].stats.Kills; kills != 1 {
+		t.Errorf("Kills of user 1 is wrong, hope %d, get %d.", 1, kills)
+	}
+	if deaths := sessions[2].stats.Deaths; deaths != 1 {
+		t.Errorf("Deaths of user 2 is wrong, hope %d, get %d.", 1, deaths)
+	}
+	if deaths := sessions[1].stats.Deaths; deaths != 0 {
+		t.Errorf("Deaths of user 1 is wrong, hope %d, get %d.", 0, deaths)
+	}
+}
+
+// TestRoomSaveStatsAndStatsQuery ...
+func TestRoomSaveStatsAndStatsQuery(t *testing.T) {
+	oldStore := StatsStore()
+	SetStatsStore(store.NewMemoryStore())
+	defer SetStatsStore(oldStore)
+
+	var si *m.StatsInfo
+	checkFunc := func(bs []byte, itype m.InfoType) {
+		if itype != m.InfoStats {
+			return
+		}
+		si = new(m.StatsInfo)
+		if err := si.UnmarshalBinary(bs); err != nil {
+			t.Error(err)
+		}
+	}
+
+	r := NewRoom(20)
+	tu1 := &testUser{id: 1, checkFunc: checkFunc}
+	tu2 := &testUser{id: 2}
+	if err := r.UserJoin(tu1); err != nil {
+		t.Error(err)
+	}
+	if err := r.UserJoin(tu2); err != nil {
+		t.Error(err)
+	}
+
+	// the same collision is reported by both users, but recorded once.
+	for _, sender := range []b.UserID{1, 2} {
+		r.recordCollisions(sender, &m.CollisionsInfo{
+			CollisionInfos: []*m.CollisionInfo{
+				{
+					IDs:     []b.FullBallID{{UID: 1, ID: 3}, {UID: 2, ID: airplaneID}},
+					Damages: []b.Damage{0, 40},
+					States:  []ball.State{ball.Disappear, ball.Dead},
+				},
+			},
+		})
+	}
+
+	if err := r.UserLeft(tu1.id); err != nil {
+		t.Error(err)
+	}
+	if err := r.UserLeft(tu2.id); err != nil {
+		t.Error(err)
+	}
+
+	ps, _ := StatsStore().Stats(1)
+	if ps.Matches != 1 || ps.Kills != 1 || ps.Damage != 40 {
+		t.Errorf("Stats of user 1 is wrong, get %+v.", ps)
+	}
+	ps, _ = StatsStore().Stats(2)
+	if ps.Matches != 1 || ps.Deaths != 1 {
+		t.Errorf("Stats of user 2 is wrong, get %+v.", ps)
+	}
+
+	// query stats of user 2 by user 1.
+	r.UserJoin(tu1)
+	r.handleStatsQuery(&m.StatsQueryInfo{UID: 1, Target: 2})
+	if si == nil {
+		t.Fatal("User 1 should receive StatsInfo.")
+	}
+	if si.UID != 2 || si.Deaths != 1 {
+		t.Errorf("StatsInfo of user 2 is wrong, get %+v.", *si)
+	}
+}
@@ -11,7 +11,7 @@ import (
 	"regexp"
 )
 
-var logger = b.Log
+var logger = b.Log.Named("socket")
 
 // ListenAndServer open a server.
 func ListenAndServer(port, path string) {
@@ -73,6 +73,9 @@ func (s *socket) HandleFunc(wc *ws.Conn) {
 		}
 	}()
 
+	// frames larger than limit are discarded without being read into memory.
+	wc.MaxPayloadBytes = b.MessageMaxSize
+
 	// Random user Id (s -> c)
 	msg, uid := m.NewRandomUserIDMsg()
 	logger.Infof("random uid %d \n", uid)
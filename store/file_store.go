@@ -0,0 +1,109 @@
+package store
+
+import (
+	b "barrage-server/base"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// fileStore keeps all stats in memory and saves them into a json file after every
+// updating, so the stats survive server restarting.
+//
+// updating only happens when user leaves room, so rewriting the whole file is cheap enough.
+type fileStore struct {
+	m      sync.RWMutex
+	path   string
+	stats  map[b.UserID]PlayerStats
+	closed bool
+}
+
+// NewFileStore create a Store saving stats in the file of path, the existing stats in
+// the file will be loaded.
+func NewFileStore(path string) (Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("Invalid path of file store: %q.", path)
+	}
+
+	fs := &fileStore{
+		path:  path,
+		stats: make(map[b.UserID]PlayerStats),
+	}
+
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fs, nil
+		}
+		return nil, err
+	}
+
+	if len(bs) != 0 {
+		if err := json.Unmarshal(bs, &fs.stats); err != nil {
+			return nil, fmt.Errorf("File store %s is broken: %v.", path, err)
+		}
+	}
+	return fs, nil
+}
+
+// Stats ...
+func (fs *fileStore) Stats(uid b.UserID) (PlayerStats, error) {
+	fs.m.RLock()
+	defer fs.m.RUnlock()
+
+	if fs.closed {
+		return PlayerStats{}, ErrStoreClosed
+	}
+
+	ps, ok := fs.stats[uid]
+	if !ok {
+		ps.UID = uid
+	}
+	return ps, nil
+}
+
+// AddStats ...
+func (fs *fileStore) AddStats(uid b.UserID, delta PlayerStats) error {
+	fs.m.Lock()
+	defer fs.m.Unlock()
+
+	if fs.closed {
+		return ErrStoreClosed
+	}
+
+	ps := fs.stats[uid]
+	ps.UID = uid
+	ps.Add(delta)
+	fs.stats[uid] = ps
+
+	return fs.save()
+}
+
+// save write all stats into a temporary file, then rename it to path, so the file
+// won't be broken if server down while writing.
+func (fs *fileStore) save() error {
+	bs, err := json.Marshal(fs.stats)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := fs.path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, bs, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, fs.path)
+}
+
+// Close ...
+func (fs *fileStore) Close() error {
+	fs.m.Lock()
+	defer fs.m.Unlock()
+
+	if fs.closed {
+		return nil
+	}
+	fs.closed = true
+	return fs.save()
+}
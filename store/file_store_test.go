@@ -0,0 +1,63 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+// TestFileStore ...
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "barrage-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := path.Join(dir, "stats.json")
+
+	s, err := NewFileStore(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delta := PlayerStats{Matches: 1, Kills: 5, Deaths: 2, Damage: 300, TimePlayed: time.Minute}
+	if err := s.AddStats(20, delta); err != nil {
+		t.Error(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Error(err)
+	}
+
+	// reopen, stats should be loaded from file.
+	s, err = NewFileStore(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	ps, err := s.Stats(20)
+	if err != nil {
+		t.Error(err)
+	}
+	delta.UID = 20
+	if ps != delta {
+		t.Errorf("Stats loaded from file is wrong, hope %+v, get %+v.", delta, ps)
+	}
+}
+
+// TestFileStoreBrokenFile ...
+func TestFileStoreBrokenFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "barrage-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("{broken")
+	f.Close()
+
+	if _, err := NewFileStore(f.Name()); err == nil {
+		t.Error("NewFileStore should fail to load broken file.")
+	}
+}
@@ -0,0 +1,100 @@
+// Package store provide the interface and the default implements of the storage for
+// data which should be kept after user disconnecting, such as player statistics.
+package store
+
+import (
+	b "barrage-server/base"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrStoreClosed throw while operating a closed store.
+	ErrStoreClosed = errors.New("Store is closed.")
+)
+
+// PlayerStats is the lifetime statistics of a player.
+type PlayerStats struct {
+	UID        b.UserID      `json:"uid"`
+	Matches    uint32        `json:"matches"`
+	Kills      uint32        `json:"kills"`
+	Deaths     uint32        `json:"deaths"`
+	Damage     uint64        `json:"damage"`
+	TimePlayed time.Duration `json:"time_played"`
+}
+
+// Add accumulate delta into ps, UID of ps is not changed.
+func (ps *PlayerStats) Add(delta PlayerStats) {
+	ps.Matches += delta.Matches
+	ps.Kills += delta.Kills
+	ps.Deaths += delta.Deaths
+	ps.Damage += delta.Damage
+	ps.TimePlayed += delta.TimePlayed
+}
+
+// Store defines the storage of player statistics, it should be goroutine safe.
+type Store interface {
+	// Stats return the lifetime stats of user, if user has no record, return
+	// zero-value stats with UID.
+	Stats(uid b.UserID) (PlayerStats, error)
+	// AddStats accumulate delta into the lifetime stats of user.
+	AddStats(uid b.UserID, delta PlayerStats) error
+	// Close flush data and release resources of store.
+	Close() error
+}
+
+// memoryStore keeps all stats in a map, data will be lost after server down.
+type memoryStore struct {
+	m      sync.RWMutex
+	stats  map[b.UserID]PlayerStats
+	closed bool
+}
+
+// NewMemoryStore create a Store keeping stats in memory, it is useful for testing.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		stats: make(map[b.UserID]PlayerStats),
+	}
+}
+
+// Stats ...
+func (ms *memoryStore) Stats(uid b.UserID) (PlayerStats, error) {
+	ms.m.RLock()
+	defer ms.m.RUnlock()
+
+	if ms.closed {
+		return PlayerStats{}, ErrStoreClosed
+	}
+
+	ps, ok := ms.stats[uid]
+	if !ok {
+		ps.UID = uid
+	}
+	return ps, nil
+}
+
+// AddStats ...
+func (ms *memoryStore) AddStats(uid b.UserID, delta PlayerStats) error {
+	ms.m.Lock()
+	defer ms.m.Unlock()
+
+	if ms.closed {
+		return ErrStoreClosed
+	}
+
+	ps := ms.stats[uid]
+	ps.UID = uid
+	ps.Add(delta)
+	ms.stats[uid] = ps
+	return nil
+}
+
+// Close ...
+func (ms *memoryStore) Close() error {
+	ms.m.Lock()
+	defer ms.m.Unlock()
+
+	ms.closed = true
+	return nil
+}
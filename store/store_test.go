@@ -0,0 +1,52 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPlayerStatsAdd ...
+func TestPlayerStatsAdd(t *testing.T) {
+	ps := PlayerStats{UID: 1, Matches: 1, Kills: 2, Deaths: 3, Damage: 4, TimePlayed: time.Second}
+	ps.Add(PlayerStats{UID: 2, Matches: 1, Kills: 1, Deaths: 1, Damage: 1, TimePlayed: time.Second})
+
+	if ps.UID != 1 {
+		t.Errorf("UID of stats should not be changed, hope %d, get %d.", 1, ps.UID)
+	}
+	if ps.Matches != 2 || ps.Kills != 3 || ps.Deaths != 4 || ps.Damage != 5 {
+		t.Errorf("Result of Add is wrong, get %+v.", ps)
+	}
+	if ps.TimePlayed != 2*time.Second {
+		t.Errorf("TimePlayed is wrong, hope %v, get %v.", 2*time.Second, ps.TimePlayed)
+	}
+}
+
+// TestMemoryStore ...
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore()
+
+	ps, err := s.Stats(10)
+	if err != nil {
+		t.Error(err)
+	}
+	if ps.UID != 10 || ps.Matches != 0 {
+		t.Errorf("Stats of unknown user should be empty, get %+v.", ps)
+	}
+
+	if err := s.AddStats(10, PlayerStats{Matches: 1, Kills: 2}); err != nil {
+		t.Error(err)
+	}
+	if err := s.AddStats(10, PlayerStats{Matches: 1, Deaths: 1}); err != nil {
+		t.Error(err)
+	}
+
+	ps, _ = s.Stats(10)
+	if ps.Matches != 2 || ps.Kills != 2 || ps.Deaths != 1 {
+		t.Errorf("Stats of user is wrong, get %+v.", ps)
+	}
+
+	s.Close()
+	if _, err := s.Stats(10); err != ErrStoreClosed {
+		t.Errorf("Closed store should return ErrStoreClosed, but get %v.", err)
+	}
+}
@@ -2,19 +2,21 @@ package main
 
 import (
 	b "barrage-server/base"
+	"barrage-server/client"
 	"barrage-server/libs/cmdface"
 	m "barrage-server/message"
 	tm "barrage-server/testLib/message"
-	"encoding/binary"
+	"flag"
 	"fmt"
-	"golang.org/x/net/websocket"
-	"io"
+	"os"
+	"path"
 	"strconv"
+	"strings"
 )
 
 var ipkgsLinkList *infoPkgNode
 var tailOfLinkList *infoPkgNode
-var websocketConn *websocket.Conn
+var cli *client.Client
 var infoTypeMap = map[m.InfoType]string{
 	m.InfoDisconnect:     "disconnect info",
 	m.InfoGameOver:       "gameover info",
@@ -22,18 +24,30 @@ var infoTypeMap = map[m.InfoType]string{
 	m.InfoSpecialMessage: "specialmessage info",
 	m.InfoConnect:        "connect info",
 	m.InfoConnected:      "connected info",
+	m.InfoStats:          "stats info",
+	m.InfoStatsQuery:     "stats query info",
+	m.InfoChat:           "chat info",
+	m.InfoPing:           "ping info",
+	m.InfoPong:           "pong info",
+	m.InfoClockSync:      "clock sync info",
+	m.InfoQuickPlay:      "quick play info",
+	m.InfoRoomList:       "room list info",
+	m.InfoRoomListQuery:  "room list query info",
+	m.InfoHeartbeat:      "heartbeat info",
 }
-var uid b.UserID
 
-var isWsConnected = false
+// trace show every received message decoded.
+var trace = false
 
 type infoPkgNode struct {
+	msg  m.Message
 	ipkg m.InfoPkg
 	next *infoPkgNode
 }
 
-func pushInfoPkg(ipkg m.InfoPkg) {
+func pushInfoPkg(msg m.Message, ipkg m.InfoPkg) {
 	newNode := &infoPkgNode{
+		msg:  msg,
 		ipkg: ipkg,
 	}
 	if ipkgsLinkList == nil {
@@ -45,52 +59,36 @@ func pushInfoPkg(ipkg m.InfoPkg) {
 	}
 }
 
+// handleInfoPkg is called for every info package received from server.
+func handleInfoPkg(msg m.Message, ipkg m.InfoPkg) {
+	if trace {
+		cmdface.Show(describeMessage(msg))
+	}
+	pushInfoPkg(msg, ipkg)
+	cmdface.Emit(infoTypeMap[ipkg.Type()])
+}
+
 func connToServer(port int, path string) error {
-	origin := "http://localhost/"
 	url := fmt.Sprintf("ws://localhost:%d%s", port, path)
-	wc, err := websocket.Dial(url, "", origin)
+	c, err := client.DialConfig(url, client.Config{
+		Handler: handleInfoPkg,
+		OnError: func(err error) {
+			cmdface.Show(fmt.Sprintf("%s\n", err.Error()))
+		},
+	})
 	if err != nil {
 		return err
 	}
 
-	websocketConn = wc
-	isWsConnected = true
-
-	go func() {
-		var bs []byte
-		for {
-			if err := websocket.Message.Receive(wc, &bs); err != nil {
-				if err == io.EOF {
-					break
-				}
-				cmdface.Show(err.Error())
-			}
-
-			msg, err := m.NewMessageFromBytes(bs)
-			if err != nil {
-				cmdface.Show(err.Error())
-			}
-
-			if msg.Type() == m.MsgRandomUserID {
-				uid = b.UserID(binary.BigEndian.Uint32(msg.Body()))
-				continue
-			}
-
-			ipkg, err := m.NewInfoPkgFromMsg(msg)
-			if err != nil {
-				cmdface.Show(err.Error())
-			}
-			pushInfoPkg(ipkg)
-		}
-	}()
-
+	cli = c
+	cmdface.SetVar("uid", strconv.Itoa(int(cli.UID())))
+	cmdface.Emit("uid")
 	return nil
 }
 
 func closeConnect() {
-	if websocketConn != nil {
-		websocketConn.Close()
-		isWsConnected = false
+	if cli != nil {
+		cli.Close()
 	}
 }
 
@@ -98,39 +96,10 @@ func cleanInfoPkgs() {
 	ipkgsLinkList = nil
 }
 
-func sendMessage(ipkg m.InfoPkg) error {
-	msg, err := m.NewMessageFromInfoPkg(ipkg)
-	if err != nil {
-		return err
-	}
-
-	bs, _ := msg.MarshalBinary()
-	websocketConn.Write(bs)
-	return nil
-}
-
-func sendConnectInfo(rid b.RoomID) error {
-	ci := &m.ConnectInfo{
-		UID: uid,
-		RID: rid,
-	}
-
-	return sendMessage(ci)
-}
-
-func sendDisconnectInfo(rid b.RoomID) error {
-	di := &m.DisconnectInfo{
-		UID: uid,
-		RID: rid,
-	}
-
-	return sendMessage(di)
-}
-
 func sendPlaygroundInfo(cin, din, nin, dsin int) error {
-	pi := tm.GenerateTestRandomPlaygroundInfo(uid, nin, din, cin, dsin)
+	pi := tm.GenerateTestRandomPlaygroundInfo(cli.UID(), nin, din, cin, dsin)
 
-	return sendMessage(pi)
+	return cli.SendSelfInfo(pi)
 }
 
 func showInfoPkgList() {
@@ -162,19 +131,7 @@ func showInfoPkg(n int) {
 		return
 	}
 
-	ipkg := ipkgNode.ipkg
-	cmdface.Show(fmt.Sprintf("%s:\n", infoTypeMap[ipkg.Type()]))
-
-	switch ipkg.Type() {
-	case m.InfoPlayground:
-		pi := ipkg.Body().(*m.PlaygroundInfo)
-		cmdface.Show(fmt.Sprintf("Balls: %d\n", pi.Displacements.Length()))
-		cmdface.Show(fmt.Sprintf("NewBalls: %d\n", pi.NewBalls.Length()))
-		cmdface.Show(fmt.Sprintf("Collisions: %d\n", pi.Collisions.Length()))
-	default:
-		cmdface.Show(fmt.Sprintf("v: %v\n", ipkg.Body()))
-	}
-
+	cmdface.Show(describeMessage(ipkgNode.msg))
 }
 
 func showInfoPkgFunc(params []string) {
@@ -189,8 +146,24 @@ func showInfoPkgListFunc(params []string) {
 	showInfoPkgList()
 }
 
+func decodeHexFunc(params []string) {
+	msg, err := decodeHexDump(strings.Join(params, " "))
+	if err != nil {
+		cmdface.Show(fmt.Sprintf("%s\n", err.Error()))
+		return
+	}
+	cmdface.Show(describeMessage(msg))
+}
+
+func traceFunc(params []string) {
+	if len(params) > 0 {
+		trace = params[0] == "on"
+	}
+	cmdface.Show(fmt.Sprintf("trace: %v.\n", trace))
+}
+
 func showUidFunc(params []string) {
-	cmdface.Show(fmt.Sprintf("uid: %d.\n", uid))
+	cmdface.Show(fmt.Sprintf("uid: %d.\n", cli.UID()))
 }
 
 func cleanInfoPkgListFunc(params []string) {
@@ -202,7 +175,11 @@ func sendConnectInfoFunc(params []string) {
 	if err != nil {
 		cmdface.Show(err.Error())
 	}
-	if err = sendConnectInfo(b.RoomID(rid)); err != nil {
+	credential := ""
+	if len(params) > 1 {
+		credential = params[1]
+	}
+	if err = cli.JoinRoom(b.RoomID(rid), credential); err != nil {
 		cmdface.Show(err.Error())
 	}
 }
@@ -212,7 +189,14 @@ func sendDisconnectInfoFunc(params []string) {
 	if err != nil {
 		cmdface.Show(err.Error())
 	}
-	if err = sendDisconnectInfo(b.RoomID(rid)); err != nil {
+	if err = cli.Leave(b.RoomID(rid)); err != nil {
+		cmdface.Show(err.Error())
+	}
+}
+
+func sendRoomListQueryInfoFunc(params []string) {
+	subscribe := len(params) > 0 && params[0] == "sub"
+	if err := cli.QueryRooms(subscribe); err != nil {
 		cmdface.Show(err.Error())
 	}
 }
@@ -240,16 +224,29 @@ func sendPlaygroundInfoFunc(params []string) {
 	}
 }
 
+var script string
+
+func init() {
+	flag.StringVar(&script, "script", "", "run commands in script file then exit, "+
+		"'wait <package name>' waits for info package such as \"connected info\", $uid is the user id")
+	flag.BoolVar(&trace, "trace", false, "show every received message decoded")
+}
+
 func main() {
+	flag.Parse()
 
 	cmdface.AddCommand(
 		"sci",
-		"<rid> <string>, join a room",
+		"<rid> [credential], join a room, credential is the password or invite code of private room",
 		sendConnectInfoFunc)
 	cmdface.AddCommand(
 		"sdi",
 		"<rid>, left a room",
 		sendDisconnectInfoFunc)
+	cmdface.AddCommand(
+		"srl",
+		"[sub], query room list, subscribe updates of rooms with 'sub'",
+		sendRoomListQueryInfoFunc)
 	cmdface.AddCommand(
 		"spi",
 		"<nin> <din> <cin> <dsin>, send playground information",
@@ -260,18 +257,60 @@ func main() {
 		showUidFunc)
 	cmdface.AddCommand(
 		"pkg",
-		"show the specialification of info packages",
+		"<n>, show the n-th received message decoded",
 		showInfoPkgFunc)
 	cmdface.AddCommand(
 		"pkgs",
 		"show all received info packages",
 		showInfoPkgListFunc)
+	cmdface.AddCommand(
+		"hex",
+		"<hex dump>, decode a message from hex dump such as '00 00 00 11 ...'",
+		decodeHexFunc)
+	cmdface.AddCommand(
+		"trace",
+		"[on|off], show every received message decoded",
+		traceFunc)
 	cmdface.AddCommand(
 		"clean",
 		"clean all packages",
 		cleanInfoPkgListFunc)
+	cmdface.SetCompleter("srl", func(args []string) []string {
+		if len(args) == 1 {
+			return []string{"sub"}
+		}
+		return nil
+	})
+	cmdface.SetCompleter("trace", func(args []string) []string {
+		if len(args) == 1 {
+			return []string{"on", "off"}
+		}
+		return nil
+	})
+
+	if err := connToServer(2334, "/test"); err != nil {
+		cmdface.Show(fmt.Sprintf("%s\n", err.Error()))
+		os.Exit(1)
+	}
+
+	if script != "" {
+		err := cmdface.RunScriptFile(script)
+		closeConnect()
+		if err != nil {
+			cmdface.Show(fmt.Sprintf("%s\n", err.Error()))
+			os.Exit(1)
+		}
+		return
+	}
+
+	historyFile := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		historyFile = path.Join(home, ".barrage_test_client_history")
+	}
+	if err := cmdface.EnableLineEditing(historyFile); err != nil {
+		cmdface.Show(fmt.Sprintf("Line editing is disabled: %s\n", err))
+	}
 
-	connToServer(2334, "/test")
 	for {
 		if err := cmdface.InputAndRunCommand(">>> "); err != nil {
 			cmdface.Show(fmt.Sprintf("%s\n", err.Error()))
@@ -0,0 +1,106 @@
+package main
+
+import (
+	b "barrage-server/base"
+	m "barrage-server/message"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const timestampLayout = "2006-01-02 15:04:05.000000"
+
+var errEmptyHexDump = errors.New("Empty hex dump.")
+
+// describeMessage show header and fully decoded body of msg as a tree.
+func describeMessage(msg m.Message) string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "Message %v, %d bytes, timestamp %s\n",
+		msg.Type(), msg.Size(), msg.Timestamp().Format(timestampLayout))
+
+	if msg.Type() == m.MsgRandomUserID {
+		if len(msg.Body()) != 4 {
+			fmt.Fprintf(&buf, "  Body is broken: % x\n", msg.Body())
+			return buf.String()
+		}
+		fmt.Fprintf(&buf, "  UID: %d\n", binary.BigEndian.Uint32(msg.Body()))
+		return buf.String()
+	}
+
+	ipkg, err := m.NewInfoPkgFromMsg(msg)
+	if err != nil {
+		fmt.Fprintf(&buf, "  Body is broken: %s\n  % x\n", err, msg.Body())
+		return buf.String()
+	}
+	describeInfoPkg(&buf, ipkg)
+
+	return buf.String()
+}
+
+// describeInfoPkg show body of ipkg, all items of lists are shown.
+func describeInfoPkg(buf *bytes.Buffer, ipkg m.InfoPkg) {
+	fmt.Fprintf(buf, "  %s:\n", infoTypeMap[ipkg.Type()])
+
+	switch info := ipkg.Body().(type) {
+	case *m.PlaygroundInfo:
+		describeBalls(buf, "NewBalls", info.NewBalls)
+		describeBalls(buf, "Displacements", info.Displacements)
+		describeCollisions(buf, info.Collisions)
+		describeDisappears(buf, info.Disappears)
+	case *m.RoomListInfo:
+		fmt.Fprintf(buf, "    Rooms: %d\n", len(info.Rooms))
+		for i, rs := range info.Rooms {
+			fmt.Fprintf(buf, "      [%d] %+v\n", i, *rs)
+		}
+	default:
+		fmt.Fprintf(buf, "    %+v\n", info)
+	}
+}
+
+func describeBalls(buf *bytes.Buffer, name string, bsi *m.BallsInfo) {
+	fmt.Fprintf(buf, "    %s: %d\n", name, bsi.Length())
+	for i, bl := range bsi.BallInfos {
+		fmt.Fprintf(buf, "      [%d] %v\n", i, bl)
+	}
+}
+
+func describeCollisions(buf *bytes.Buffer, csi *m.CollisionsInfo) {
+	fmt.Fprintf(buf, "    Collisions: %d\n", csi.Length())
+	for i, ci := range csi.CollisionInfos {
+		fmt.Fprintf(buf, "      [%d] %s <-> %s damages=(%d,%d) states=(%v,%v)\n", i,
+			fullBallID(ci.IDs[0]), fullBallID(ci.IDs[1]),
+			ci.Damages[0], ci.Damages[1], ci.States[0], ci.States[1])
+	}
+}
+
+func describeDisappears(buf *bytes.Buffer, dsi *m.DisappearsInfo) {
+	fmt.Fprintf(buf, "    Disappears: %d\n", len(dsi.IDs))
+	if len(dsi.IDs) > 0 {
+		fmt.Fprintf(buf, "      %v\n", dsi.IDs)
+	}
+}
+
+// fullBallID show id of ball as uid:id.
+func fullBallID(id b.FullBallID) string {
+	return fmt.Sprintf("%d:%d", id.UID, id.ID)
+}
+
+// decodeHexDump create message from a hex dump, bytes may be separated by spaces,
+// colons or commas, and may be prefixed by 0x.
+func decodeHexDump(dump string) (m.Message, error) {
+	dump = strings.NewReplacer("0x", "", "0X", "", ":", "", ",", "").Replace(dump)
+	dump = strings.Join(strings.Fields(dump), "")
+	if dump == "" {
+		return nil, errEmptyHexDump
+	}
+
+	bs, err := hex.DecodeString(dump)
+	if err != nil {
+		return nil, err
+	}
+	return m.NewMessageFromBytes(bs)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	m "barrage-server/message"
+	tm "barrage-server/testLib/message"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDescribePlaygroundMessage(t *testing.T) {
+	msg, err := m.NewMessageFromInfoPkg(tm.GenerateTestPlaygroundInfo(tm.UidA, 2, 1, 1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := describeMessage(msg)
+	for _, hope := range []string{
+		"Message Playground(0x07)",
+		"playground info:",
+		"NewBalls: 2\n      [0] uid=0 id=99",
+		"      [1] uid=0 id=99",
+		"Displacements: 1",
+		"Collisions: 1\n      [0] 10:1 <-> 100:1 damages=(120,130) states=(alive,dead)",
+		"Disappears: 1\n      [99]",
+	} {
+		if !strings.Contains(s, hope) {
+			t.Errorf("Description of message is wrong, hope containing %q, get:\n%s", hope, s)
+		}
+	}
+}
+
+func TestDecodeHexDump(t *testing.T) {
+	msg := m.NewMessage(m.MsgPing, []byte{0, 0, 0, 7, 0, 0, 0, 0, 0, 0, 0, 0})
+	bs, _ := msg.MarshalBinary()
+
+	// separated bytes and continuous bytes are both accepted.
+	dump := fmt.Sprintf("% x\n0x%s", bs[:6], hex.EncodeToString(bs[6:]))
+	decoded, err := decodeHexDump(dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Type() != m.MsgPing {
+		t.Errorf("Type of message is wrong, hope %v, get %v.", m.MsgPing, decoded.Type())
+	}
+	if s := describeMessage(decoded); !strings.Contains(s, "Seq:7") {
+		t.Errorf("Description of ping is wrong, hope containing Seq:7, get:\n%s", s)
+	}
+
+	if _, err := decodeHexDump("00 01"); err == nil {
+		t.Error("Broken message should not be decoded.")
+	}
+	if _, err := decodeHexDump(" "); err != errEmptyHexDump {
+		t.Errorf("Error of empty dump is wrong, hope %v, get %v.", errEmptyHexDump, err)
+	}
+}
@@ -11,6 +11,8 @@ type TestBall struct {
 	Uid      b.UserID
 	Id       b.BallID
 	Nickname string
+
+	X, Y, R uint16
 }
 
 func (bl *TestBall) UID() b.UserID {
@@ -21,6 +23,14 @@ func (bl *TestBall) ID() b.BallID {
 	return bl.Id
 }
 
+func (bl *TestBall) Position() (x, y uint16) {
+	return bl.X, bl.Y
+}
+
+func (bl *TestBall) Radius() uint16 {
+	return bl.R
+}
+
 func (bl *TestBall) Size() int {
 	return 7 + len(bl.Nickname)
 }
@@ -0,0 +1,125 @@
+package user
+
+import (
+	b "barrage-server/base"
+	"barrage-server/libs/ratelimit"
+	m "barrage-server/message"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// actions of flood protection
+	floodAllow = iota
+	floodDrop
+	floodWarn
+	floodDisconnect
+)
+
+// FloodCounters counts the actions of flood protection of all users, it is used for monitoring.
+type FloodCounters struct {
+	Dropped      uint64 `json:"dropped"`
+	Warned       uint64 `json:"warned"`
+	Disconnected uint64 `json:"disconnected"`
+}
+
+var floodCounters FloodCounters
+
+// FloodStats return a snapshot of flood counters.
+func FloodStats() FloodCounters {
+	return FloodCounters{
+		Dropped:      atomic.LoadUint64(&floodCounters.Dropped),
+		Warned:       atomic.LoadUint64(&floodCounters.Warned),
+		Disconnected: atomic.LoadUint64(&floodCounters.Disconnected),
+	}
+}
+
+// rateLimit is the parameters of token bucket for a type of message.
+type rateLimit struct {
+	rate  float64
+	burst int
+}
+
+// rateLimitFor return the rate limit of message type t in a room whose tick duration
+// is tick, b.RoomBoardCastDuration is used if tick is 0.
+func rateLimitFor(t m.MsgType, tick time.Duration) rateLimit {
+	switch t {
+	case m.MsgUserSelf:
+		// user self info is sent about once per tick of room.
+		if tick <= 0 {
+			tick = b.RoomBoardCastDuration
+		}
+		tickRate := float64(time.Second) / float64(tick)
+		return rateLimit{rate: tickRate * 1.5, burst: int(tickRate)}
+	case m.MsgConnect, m.MsgDisconnect:
+		return rateLimit{rate: 0.5, burst: 3}
+	default:
+		return rateLimit{rate: 5, burst: 10}
+	}
+}
+
+// floodGuard limits the rate of every type of messages from a user, and escalates its
+// response while user keeps sending over-limit messages: drop, warn, then disconnect.
+//
+// it is only used by the goroutine receiving messages, so it is not goroutine safe.
+type floodGuard struct {
+	buckets     map[m.MsgType]*ratelimit.Bucket
+	violations  int
+	windowStart time.Time
+	// tick is the tick duration of the room user is in.
+	tick time.Duration
+}
+
+// newFloodGuard ...
+func newFloodGuard() *floodGuard {
+	return &floodGuard{
+		buckets: make(map[m.MsgType]*ratelimit.Bucket),
+	}
+}
+
+// setTick change the tick duration used by the limit of MsgUserSelf, it is called
+// while user moving into another room.
+func (fg *floodGuard) setTick(tick time.Duration) {
+	if tick != fg.tick {
+		fg.tick = tick
+		delete(fg.buckets, m.MsgUserSelf)
+	}
+}
+
+// check take a token for message type t, then return the action for this message.
+func (fg *floodGuard) check(t m.MsgType) int {
+	bucket, ok := fg.buckets[t]
+	if !ok {
+		limit := rateLimitFor(t, fg.tick)
+		bucket = ratelimit.NewBucket(limit.rate, limit.burst)
+		fg.buckets[t] = bucket
+	}
+
+	if bucket.Allow() {
+		return floodAllow
+	}
+	return fg.violate()
+}
+
+// violate count an over-limit or broken message, then return the action for it.
+func (fg *floodGuard) violate() int {
+	// count over-limit messages in window.
+	now := time.Now()
+	if now.Sub(fg.windowStart) > b.FloodWindow {
+		fg.windowStart = now
+		fg.violations = 0
+	}
+	fg.violations++
+
+	switch {
+	case fg.violations >= b.FloodDisconnectThreshold:
+		atomic.AddUint64(&floodCounters.Disconnected, 1)
+		return floodDisconnect
+	case fg.violations == b.FloodWarnThreshold:
+		atomic.AddUint64(&floodCounters.Warned, 1)
+		return floodWarn
+	default:
+		atomic.AddUint64(&floodCounters.Dropped, 1)
+		return floodDrop
+	}
+}
@@ -0,0 +1,89 @@
+package user
+
+import (
+	b "barrage-server/base"
+	m "barrage-server/message"
+	"testing"
+	"time"
+)
+
+// TestFloodGuardEscalation ...
+func TestFloodGuardEscalation(t *testing.T) {
+	fg := newFloodGuard()
+	before := FloodStats()
+
+	burst := rateLimitFor(m.MsgConnect, 0).burst
+	for i := 0; i < burst; i++ {
+		if action := fg.check(m.MsgConnect); action != floodAllow {
+			t.Errorf("Message in burst should be allowed, hope %d, get %d.", floodAllow, action)
+		}
+	}
+
+	// other type of message has its own bucket.
+	if action := fg.check(m.MsgUserSelf); action != floodAllow {
+		t.Errorf("MsgUserSelf should be allowed, hope %d, get %d.", floodAllow, action)
+	}
+
+	for i := 1; i < b.FloodDisconnectThreshold; i++ {
+		action := fg.check(m.MsgConnect)
+		hope := floodDrop
+		if i == b.FloodWarnThreshold {
+			hope = floodWarn
+		}
+		if action != hope {
+			t.Errorf("Action of the %dth over-limit message is wrong, hope %d, get %d.", i, hope, action)
+		}
+	}
+	if action := fg.check(m.MsgConnect); action != floodDisconnect {
+		t.Errorf("User should be disconnected, hope %d, get %d.", floodDisconnect, action)
+	}
+
+	after := FloodStats()
+	if n := after.Dropped - before.Dropped; n != uint64(b.FloodDisconnectThreshold-2) {
+		t.Errorf("Dropped counter is wrong, hope %d, get %d.", b.FloodDisconnectThreshold-2, n)
+	}
+	if n := after.Warned - before.Warned; n != 1 {
+		t.Errorf("Warned counter is wrong, hope %d, get %d.", 1, n)
+	}
+	if n := after.Disconnected - before.Disconnected; n != 1 {
+		t.Errorf("Disconnected counter is wrong, hope %d, get %d.", 1, n)
+	}
+}
+
+// TestFloodGuardViolate ...
+func TestFloodGuardViolate(t *testing.T) {
+	fg := newFloodGuard()
+
+	for i := 1; i < b.FloodDisconnectThreshold; i++ {
+		if action := fg.violate(); action == floodDisconnect {
+			t.Fatalf("User should not be disconnected after %d violations.", i)
+		}
+	}
+	if action := fg.violate(); action != floodDisconnect {
+		t.Errorf("User should be disconnected, hope %d, get %d.", floodDisconnect, action)
+	}
+}
+
+// TestFloodGuardTick ...
+func TestFloodGuardTick(t *testing.T) {
+	fast := rateLimitFor(m.MsgUserSelf, time.Millisecond*10)
+	slow := rateLimitFor(m.MsgUserSelf, time.Millisecond*100)
+	if fast.rate <= slow.rate {
+		t.Errorf("Faster tick should allow more messages, get %v and %v.", fast.rate, slow.rate)
+	}
+
+	fg := newFloodGuard()
+	fg.setTick(time.Millisecond * 100)
+	for i := 0; i < slow.burst; i++ {
+		fg.check(m.MsgUserSelf)
+	}
+	if action := fg.check(m.MsgUserSelf); action == floodAllow {
+		t.Error("Messages more than burst should not be allowed.")
+	}
+
+	// bucket is recreated in a room with another tick.
+	fg.setTick(time.Millisecond * 10)
+	if action := fg.check(m.MsgUserSelf); action != floodAllow {
+		t.Errorf("MsgUserSelf should be allowed in new room, hope %d, get %d.", floodAllow, action)
+	}
+}
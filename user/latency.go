@@ -0,0 +1,121 @@
+package user
+
+import (
+	b "barrage-server/base"
+	m "barrage-server/message"
+	"sync"
+	"time"
+)
+
+// latencyWeight is the weight of a new sample in the smoothed latency, like SRTT of tcp.
+const latencyWeight = 0.125
+
+// Latency is the network latency and clock offset of a user measured by ping/pong.
+type Latency struct {
+	RTT time.Duration `json:"rtt"`
+	// Offset is the clock of frontend minus the clock of server.
+	Offset  time.Duration `json:"offset"`
+	Samples int           `json:"samples"`
+}
+
+// latencyMeter sends pings and measures latency from pongs.
+type latencyMeter struct {
+	m sync.Mutex
+
+	seq    uint32
+	sentAt time.Time
+	// pending is true when the last ping is not replied.
+	pending bool
+
+	latency Latency
+}
+
+// ping create the next PingInfo, the former ping is dropped if it is not replied.
+func (lm *latencyMeter) ping(now time.Time) *m.PingInfo {
+	lm.m.Lock()
+	defer lm.m.Unlock()
+
+	lm.seq++
+	lm.sentAt = now
+	lm.pending = true
+	return &m.PingInfo{Seq: lm.seq, ServerTime: float64(now.UnixNano())}
+}
+
+// pong measure latency from po, clientTime is the time of frontend sending pong and
+// now is the time of server receiving it. It returns false if po doesn't reply the
+// last ping.
+func (lm *latencyMeter) pong(po *m.PongInfo, clientTime, now time.Time) bool {
+	lm.m.Lock()
+	defer lm.m.Unlock()
+
+	if !lm.pending || po.Seq != lm.seq {
+		return false
+	}
+	lm.pending = false
+
+	rtt := now.Sub(lm.sentAt)
+	// frontend is assumed to reply at the middle of the round trip.
+	offset := clientTime.Sub(lm.sentAt.Add(rtt / 2))
+
+	if lm.latency.Samples == 0 {
+		lm.latency.RTT = rtt
+		lm.latency.Offset = offset
+	} else {
+		lm.latency.RTT += time.Duration(latencyWeight * float64(rtt-lm.latency.RTT))
+		lm.latency.Offset += time.Duration(latencyWeight * float64(offset-lm.latency.Offset))
+	}
+	lm.latency.Samples++
+	return true
+}
+
+// get ...
+func (lm *latencyMeter) get() Latency {
+	lm.m.Lock()
+	defer lm.m.Unlock()
+
+	return lm.latency
+}
+
+// Latency ...
+func (u *user) Latency() Latency {
+	return u.latency.get()
+}
+
+// perceivedTime convert clientTime of a message into the clock of server, then
+// return the time of playground user saw when sending the message, which is half
+// a round trip earlier. It returns now if latency is not measured yet.
+func (u *user) perceivedTime(clientTime time.Time) time.Time {
+	l := u.latency.get()
+	if l.Samples == 0 {
+		return time.Now()
+	}
+	return clientTime.Add(-l.Offset).Add(-l.RTT / 2)
+}
+
+// keepPinging send ping to frontend every PingInterval until stop is closed.
+func (u *user) keepPinging(stop <-chan struct{}) {
+	ticker := time.NewTicker(b.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			u.Send(u.latency.ping(now))
+		}
+	}
+}
+
+// handlePong update latency by pong, then tell frontend the clock offset.
+func (u *user) handlePong(po *m.PongInfo, clientTime time.Time) {
+	if !u.latency.pong(po, clientTime, time.Now()) {
+		return
+	}
+
+	l := u.latency.get()
+	if l.RTT > b.PingInterval {
+		logger.Warnf("User %d has a high latency %v. \n", u.uid, l.RTT)
+	}
+	u.sendInfoPkg(&m.ClockSyncInfo{RTT: float64(l.RTT), Offset: float64(l.Offset)})
+}
@@ -0,0 +1,61 @@
+package user
+
+import (
+	m "barrage-server/message"
+	"testing"
+	"time"
+)
+
+// TestLatencyMeter ...
+func TestLatencyMeter(t *testing.T) {
+	var lm latencyMeter
+
+	sentAt := time.Now()
+	pi := lm.ping(sentAt)
+
+	// pong of other ping should be ignored.
+	if lm.pong(&m.PongInfo{Seq: pi.Seq + 1}, sentAt, sentAt) {
+		t.Error("Pong with wrong seq should be ignored.")
+	}
+
+	// frontend is 1s ahead of server, and rtt is 100ms.
+	clientTime := sentAt.Add(time.Second + 50*time.Millisecond)
+	if !lm.pong(&m.PongInfo{Seq: pi.Seq}, clientTime, sentAt.Add(100*time.Millisecond)) {
+		t.Error("Pong should be accepted.")
+	}
+	// the same pong should not be accepted twice.
+	if lm.pong(&m.PongInfo{Seq: pi.Seq}, clientTime, sentAt.Add(100*time.Millisecond)) {
+		t.Error("Pong should not be accepted twice.")
+	}
+
+	l := lm.get()
+	if l.RTT != 100*time.Millisecond {
+		t.Errorf("RTT is wrong, hope %v, get %v.", 100*time.Millisecond, l.RTT)
+	}
+	if l.Offset != time.Second {
+		t.Errorf("Offset is wrong, hope %v, get %v.", time.Second, l.Offset)
+	}
+
+	// new samples are smoothed.
+	sentAt = sentAt.Add(time.Second)
+	pi = lm.ping(sentAt)
+	lm.pong(&m.PongInfo{Seq: pi.Seq}, sentAt.Add(time.Second+450*time.Millisecond), sentAt.Add(900*time.Millisecond))
+	if l = lm.get(); l.RTT != 200*time.Millisecond || l.Samples != 2 {
+		t.Errorf("Smoothed latency is wrong, get %+v.", l)
+	}
+}
+
+// TestPerceivedTime ...
+func TestPerceivedTime(t *testing.T) {
+	u := &user{uid: 1}
+	if pt := u.perceivedTime(time.Now().Add(time.Hour)); time.Since(pt) > time.Second {
+		t.Errorf("Perceived time should be now without latency, get %v.", pt)
+	}
+
+	u.latency.latency = Latency{RTT: 100 * time.Millisecond, Offset: time.Second, Samples: 1}
+	clientTime := time.Now()
+	hope := clientTime.Add(-time.Second - 50*time.Millisecond)
+	if pt := u.perceivedTime(clientTime); !pt.Equal(hope) {
+		t.Errorf("Perceived time is wrong, hope %v, get %v.", hope, pt)
+	}
+}
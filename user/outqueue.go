@@ -0,0 +1,160 @@
+package user
+
+import (
+	b "barrage-server/base"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	errQueueClosed = errors.New("Outbound queue is closed")
+	errSlowClient  = errors.New("Client is too slow to receive messages")
+)
+
+// outQueue is the bounded outbound queue of a user, all messages to frontend are
+// sent by a single writer which pops bytes from it.
+//
+// Control messages (ConnectedInfo, GameOverInfo, errors...) are kept in order and
+// popped before playground frames, only the newest playground frame is kept since
+// the older ones are superseded.
+type outQueue struct {
+	m    sync.Mutex
+	cond *sync.Cond
+
+	size    int
+	control [][]byte
+	frame   []byte
+	closed  bool
+	// draining is true when queue refuses new messages and will be closed after
+	// the queued ones are sent, drained is true after that.
+	draining bool
+	drained  bool
+
+	// idle is true when writer has sent all messages and is waiting.
+	idle bool
+	// caughtUp is the last time writer was found idle.
+	caughtUp time.Time
+	// superseded counts the frames dropped before being sent.
+	superseded uint64
+}
+
+// newOutQueue create an outQueue holds at most size control messages.
+func newOutQueue(size int) *outQueue {
+	oq := &outQueue{
+		size:     size,
+		control:  make([][]byte, 0, size),
+		idle:     true,
+		caughtUp: time.Now(),
+	}
+	oq.cond = sync.NewCond(&oq.m)
+	return oq
+}
+
+// push put bs into queue, a frame replaces the pending frame. If control messages
+// overflow or writer has stayed behind longer than OutboundLagLimit, queue is closed
+// and errSlowClient is returned.
+func (oq *outQueue) push(bs []byte, isFrame bool) error {
+	oq.m.Lock()
+	defer oq.m.Unlock()
+
+	if oq.closed || oq.draining {
+		return errQueueClosed
+	}
+
+	if !oq.idle && time.Since(oq.caughtUp) > b.OutboundLagLimit {
+		oq.closeLocked()
+		return errSlowClient
+	}
+
+	if isFrame {
+		if oq.frame != nil {
+			oq.superseded++
+		}
+		oq.frame = bs
+	} else {
+		if len(oq.control) >= oq.size {
+			oq.closeLocked()
+			return errSlowClient
+		}
+		oq.control = append(oq.control, bs)
+	}
+
+	oq.cond.Signal()
+	return nil
+}
+
+// pop block until there is a message in queue or queue is closed, control messages
+// are returned first. It returns false if queue is closed.
+func (oq *outQueue) pop() ([]byte, bool) {
+	oq.m.Lock()
+	defer oq.m.Unlock()
+
+	for {
+		if oq.closed {
+			return nil, false
+		}
+
+		var bs []byte
+		if len(oq.control) > 0 {
+			bs = oq.control[0]
+			oq.control[0] = nil
+			oq.control = oq.control[1:]
+		} else if oq.frame != nil {
+			bs = oq.frame
+			oq.frame = nil
+		}
+
+		if bs != nil {
+			// writer has been waiting until now, so it is not behind.
+			if oq.idle {
+				oq.idle = false
+				oq.caughtUp = time.Now()
+			}
+			return bs, true
+		}
+
+		if oq.draining {
+			oq.drained = true
+			oq.closeLocked()
+			return nil, false
+		}
+
+		oq.idle = true
+		oq.caughtUp = time.Now()
+		oq.cond.Wait()
+	}
+}
+
+// drain refuse new messages, and close queue after queued messages are popped.
+func (oq *outQueue) drain() {
+	oq.m.Lock()
+	defer oq.m.Unlock()
+
+	oq.draining = true
+	oq.cond.Broadcast()
+}
+
+// isDrained return true if queue is closed by drain.
+func (oq *outQueue) isDrained() bool {
+	oq.m.Lock()
+	defer oq.m.Unlock()
+
+	return oq.drained
+}
+
+// close drop all messages in queue and wake up writer.
+func (oq *outQueue) close() {
+	oq.m.Lock()
+	defer oq.m.Unlock()
+
+	oq.closeLocked()
+}
+
+// closeLocked ...
+func (oq *outQueue) closeLocked() {
+	oq.closed = true
+	oq.control = nil
+	oq.frame = nil
+	oq.cond.Broadcast()
+}
@@ -0,0 +1,130 @@
+package user
+
+import (
+	b "barrage-server/base"
+	"testing"
+	"time"
+)
+
+// TestOutQueuePriorityAndCoalescing ...
+func TestOutQueuePriorityAndCoalescing(t *testing.T) {
+	oq := newOutQueue(4)
+
+	oq.push([]byte("frame1"), true)
+	oq.push([]byte("connected"), false)
+	oq.push([]byte("frame2"), true)
+	oq.push([]byte("gameover"), false)
+
+	hopes := []string{"connected", "gameover", "frame2"}
+	for _, hope := range hopes {
+		bs, ok := oq.pop()
+		if !ok {
+			t.Fatal("Queue should not be closed.")
+		}
+		if s := string(bs); s != hope {
+			t.Errorf("Popped message is wrong, hope %s, get %s.", hope, s)
+		}
+	}
+	if oq.superseded != 1 {
+		t.Errorf("Number of superseded frames is wrong, hope %d, get %d.", 1, oq.superseded)
+	}
+}
+
+// TestOutQueueOverflow ...
+func TestOutQueueOverflow(t *testing.T) {
+	oq := newOutQueue(2)
+
+	for i := 0; i < 2; i++ {
+		if err := oq.push([]byte("control"), false); err != nil {
+			t.Error(err)
+		}
+	}
+	// frames never overflow queue.
+	if err := oq.push([]byte("frame"), true); err != nil {
+		t.Error(err)
+	}
+
+	if err := oq.push([]byte("control"), false); err != errSlowClient {
+		t.Errorf("Overflow should be refused, hope %v, get %v.", errSlowClient, err)
+	}
+	if err := oq.push([]byte("control"), false); err != errQueueClosed {
+		t.Errorf("Queue should be closed, hope %v, get %v.", errQueueClosed, err)
+	}
+	if _, ok := oq.pop(); ok {
+		t.Error("Pop from closed queue should fail.")
+	}
+}
+
+// TestOutQueueLag ...
+func TestOutQueueLag(t *testing.T) {
+	oldLimit := b.OutboundLagLimit
+	b.OutboundLagLimit = time.Millisecond * 50
+	defer func() { b.OutboundLagLimit = oldLimit }()
+
+	oq := newOutQueue(8)
+
+	// writer is idle, so queue is never behind.
+	time.Sleep(time.Millisecond * 100)
+	if err := oq.push([]byte("frame"), true); err != nil {
+		t.Error(err)
+	}
+
+	// writer is busy with the frame and never catches up.
+	oq.pop()
+	oq.push([]byte("frame"), true)
+	time.Sleep(time.Millisecond * 100)
+	if err := oq.push([]byte("frame"), true); err != errSlowClient {
+		t.Errorf("Lagging client should be refused, hope %v, get %v.", errSlowClient, err)
+	}
+}
+
+// TestOutQueueClose ...
+func TestOutQueueClose(t *testing.T) {
+	oq := newOutQueue(2)
+
+	done := make(chan bool)
+	go func() {
+		_, ok := oq.pop()
+		done <- ok
+	}()
+
+	time.Sleep(time.Millisecond * 20)
+	oq.close()
+
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("Pop should fail after queue is closed.")
+		}
+	case <-time.After(time.Second):
+		t.Error("Writer should be woken up after queue is closed.")
+	}
+}
+
+// TestOutQueueDrain ...
+func TestOutQueueDrain(t *testing.T) {
+	oq := newOutQueue(4)
+	oq.push([]byte("frame"), true)
+	oq.push([]byte("bye"), false)
+	oq.drain()
+
+	if err := oq.push([]byte("control"), false); err != errQueueClosed {
+		t.Errorf("Draining queue should refuse messages, hope %v, get %v.", errQueueClosed, err)
+	}
+
+	for _, hope := range []string{"bye", "frame"} {
+		bs, ok := oq.pop()
+		if !ok || string(bs) != hope {
+			t.Errorf("Queued messages should be popped while draining, hope %s, get %s.", hope, bs)
+		}
+	}
+	if oq.isDrained() {
+		t.Error("Queue should not be drained before writer finds it empty.")
+	}
+	if _, ok := oq.pop(); ok {
+		t.Error("Pop should fail after queue is drained.")
+	}
+	if !oq.isDrained() {
+		t.Error("Queue should be drained.")
+	}
+}
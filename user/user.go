@@ -2,6 +2,7 @@ package user
 
 import (
 	b "barrage-server/base"
+	"barrage-server/libs/log"
 	m "barrage-server/message"
 	"errors"
 	"sync"
@@ -17,10 +18,11 @@ var (
 	errInvalidUser   = errors.New("Invalid user error")
 	errNotAllowedMsg = errors.New("Not allowed message")
 	errUserID        = errors.New("User ID error")
+	errTooFrequent   = errors.New("Messages are sent too frequently")
+	errRoomBusy      = errors.New("Room is busy")
 )
 
-var logger = b.Log
-var interval = time.Second * 2
+var logger = b.Log.Named("user")
 
 // constructErrorStringForMsg construct error string after receiving and unmarshaling message
 // according to message type and running environment.
@@ -44,16 +46,26 @@ type User interface {
 	Room() b.RoomID
 
 	//Send is used by room to send bytes to frontend.
-	//Send send bytes in a new goroutine.
+	//Send never blocks, it puts bytes into the outbound queue of user, user is
+	//disconnected if it can't keep up with the queue.
 	Send(ipkg m.InfoPkg)
-	//SendError send bytes in a new goroutine.
+	//SendError puts error message into the outbound queue of user.
 	SendError(s string)
 
-	//UploadInfo send infopkg to room via chan<- m.InfoPkg
+	//UploadInfo send infopkg to room via chan<- m.InfoPkg, it never blocks, infopkg
+	//is dropped if the channel is full.
 	UploadInfo(infopkg m.InfoPkg) error
 
-	//BindRoom set infopkg channel and room id for user to binds room and user.
-	BindRoom(id b.RoomID, c chan<- m.InfoPkg)
+	//BindRoom set infopkg channel and room id for user to binds room and user, tick
+	//is the tick duration of room, 0 means b.RoomBoardCastDuration.
+	BindRoom(id b.RoomID, c chan<- m.InfoPkg, tick time.Duration)
+
+	//Latency return the latency and clock offset measured by ping/pong.
+	Latency() Latency
+
+	//Kick send reason to frontend, then close the connection after all queued
+	//messages are sent, Play will be over.
+	Kick(reason string)
 
 	// socket package should call Play to ready user(listen messages)
 	// before call Play, socket should join user into hall, after call Play, socket
@@ -64,9 +76,10 @@ type User interface {
 // NewUser create a User by websocket.Conn and userID.
 func NewUser(wc *ws.Conn, id b.UserID) User {
 	return &user{
-		uid:       id,
-		wc:        wc,
-		writeChan: make(chan []byte, 50),
+		uid:              id,
+		wc:               wc,
+		out:              newOutQueue(b.OutboundQueueSize),
+		heartbeatTimeout: b.HeartbeatTimeout,
 	}
 }
 
@@ -81,8 +94,16 @@ type user struct {
 	roomM    sync.RWMutex
 	rid      b.RoomID
 	infoChan chan<- m.InfoPkg
+	tick     time.Duration
+
+	out     *outQueue
+	latency latencyMeter
 
-	writeChan chan []byte
+	// only be used by receiveAndUploadMessage.
+	flood *floodGuard
+	// heartbeatTimeout is copied from b.HeartbeatTimeout at creation, it is
+	// b.HeartbeatTimeout if it is 0.
+	heartbeatTimeout time.Duration
 }
 
 // ID ...
@@ -98,41 +119,35 @@ func (u *user) Room() b.RoomID {
 	return u.rid
 }
 
-// UploadInfo do base check and add it to infoChan.
+// UploadInfo do base check and add it to infoChan. if infoChan is full, playground
+// info is dropped at once, other info waits for b.InfoUploadTimeout, then errRoomBusy
+// is returned.
 func (u *user) UploadInfo(ipkg m.InfoPkg) error {
 	u.roomM.RLock()
-	defer u.roomM.RUnlock()
+	infoChan := u.infoChan
+	u.roomM.RUnlock()
 
-	if u.infoChan == nil {
+	if infoChan == nil {
 		return errInvalidUser
 	}
 
-	go func() {
-		u.infoChan <- ipkg
-	}()
-
-	return nil
-}
-
-// convertBytesToInfopkg ...
-func (u *user) convertBytesToInfopkg(cache []byte) (ipkg m.InfoPkg, msg m.Message, err error) {
-	defer func() {
-		if re := recover(); re != nil {
-			err = re.(error)
-		}
-	}()
-
-	msg, err = m.NewMessageFromBytes(cache)
-	if err != nil {
-		return nil, nil, err
+	select {
+	case infoChan <- ipkg:
+		return nil
+	default:
 	}
-
-	ipkg, err = m.NewInfoPkgFromMsg(msg)
-	if err != nil {
-		return nil, msg, err
+	if ipkg.Type() == m.InfoPlayground {
+		return errRoomBusy
 	}
 
-	return ipkg, msg, nil
+	timer := time.NewTimer(b.InfoUploadTimeout)
+	defer timer.Stop()
+	select {
+	case infoChan <- ipkg:
+		return nil
+	case <-timer.C:
+		return errRoomBusy
+	}
 }
 
 // preOperationForIpkg is a guard fucntion to filter invalid infopkgs and do some
@@ -149,6 +164,14 @@ func (u *user) preOperationForIpkg(ipkg m.InfoPkg) error {
 		return u.checkConnectInfo(ipkg.Body().(*m.ConnectInfo))
 	case m.InfoDisconnect:
 		return u.checkDisconnectInfo(ipkg.Body().(*m.DisconnectInfo))
+	case m.InfoStatsQuery:
+		return u.checkStatsQueryInfo(ipkg.Body().(*m.StatsQueryInfo))
+	case m.InfoChat:
+		return u.checkChatInfo(ipkg.Body().(*m.ChatInfo))
+	case m.InfoQuickPlay:
+		return u.checkQuickPlayInfo(ipkg.Body().(*m.QuickPlayInfo))
+	case m.InfoRoomListQuery:
+		return u.checkRoomListQueryInfo(ipkg.Body().(*m.RoomListQueryInfo))
 	default:
 		return errNotAllowedMsg
 	}
@@ -170,37 +193,74 @@ func (u *user) checkDisconnectInfo(di *m.DisconnectInfo) error {
 	return nil
 }
 
+// checkStatsQueryInfo ...
+func (u *user) checkStatsQueryInfo(sqi *m.StatsQueryInfo) error {
+	if sqi.UID != u.uid {
+		return errUserID
+	}
+	return nil
+}
+
+// checkChatInfo ...
+func (u *user) checkChatInfo(ci *m.ChatInfo) error {
+	if ci.UID != u.uid {
+		return errUserID
+	}
+	return nil
+}
+
+// checkQuickPlayInfo ...
+func (u *user) checkQuickPlayInfo(qpi *m.QuickPlayInfo) error {
+	if qpi.UID != u.uid {
+		return errUserID
+	}
+	return nil
+}
+
+// checkRoomListQueryInfo ...
+func (u *user) checkRoomListQueryInfo(rlqi *m.RoomListQueryInfo) error {
+	if rlqi.UID != u.uid {
+		return errUserID
+	}
+	return nil
+}
+
 // BindRoom ...
-func (u *user) BindRoom(id b.RoomID, c chan<- m.InfoPkg) {
+func (u *user) BindRoom(id b.RoomID, c chan<- m.InfoPkg, tick time.Duration) {
 	u.roomM.Lock()
 	defer u.roomM.Unlock()
 
 	u.rid = id
 	u.infoChan = c
+	u.tick = tick
+}
+
+// tickDuration return the tick duration of the room user is in.
+func (u *user) tickDuration() time.Duration {
+	u.roomM.RLock()
+	defer u.roomM.RUnlock()
+
+	return u.tick
 }
 
 // SendError ...
 func (u *user) SendError(s string) {
-	go func() {
-		u.stateM.RLock()
-		defer u.stateM.RUnlock()
+	u.stateM.RLock()
+	defer u.stateM.RUnlock()
 
-		if u.state == 1 {
-			u.sendError(s)
-		}
-	}()
+	if u.state == 1 {
+		u.sendError(s)
+	}
 }
 
 // Send ...
 func (u *user) Send(ipkg m.InfoPkg) {
-	go func() {
-		u.stateM.RLock()
-		defer u.stateM.RUnlock()
+	u.stateM.RLock()
+	defer u.stateM.RUnlock()
 
-		if u.state == 1 {
-			u.sendInfoPkg(ipkg)
-		}
-	}()
+	if u.state == 1 {
+		u.sendInfoPkg(ipkg)
+	}
 }
 
 // sendSpecialMessage ...
@@ -209,23 +269,61 @@ func (u *user) sendError(s string) {
 	u.sendInfoPkg(si)
 }
 
-// sendInfoPkg construct message and write bytes to wc.
+// sendInfoPkg construct message and put bytes into outbound queue, PlaygroundInfo
+// is sent as a frame which can be superseded by the next one.
 func (u *user) sendInfoPkg(ipkg m.InfoPkg) error {
 	msg, err := m.NewMessageFromInfoPkg(ipkg)
 	if err != nil {
+		logger.Errorf("Failed to construct message for user %d: %v. \n", u.uid, err)
 		return err
 	}
 
 	bs, _ := msg.MarshalBinary()
 
-	u.writeChan <- bs
-	return nil
+	err = u.out.push(bs, ipkg.Type() == m.InfoPlayground)
+	if err == errSlowClient {
+		logger.Warnf("User %d is too slow to receive messages, disconnect it. \n", u.uid)
+		u.disconnect()
+	}
+	return err
+}
+
+// Kick ...
+func (u *user) Kick(reason string) {
+	logger.Infof("User %d is kicked: %s \n", u.uid, reason)
+
+	u.stateM.RLock()
+	defer u.stateM.RUnlock()
+
+	if u.state != 1 {
+		u.disconnect()
+		return
+	}
+	u.sendError(reason)
+	u.out.drain()
+}
+
+// disconnect close websocket of user, then receiveAndUploadMessage will be over.
+func (u *user) disconnect() {
+	if u.wc != nil {
+		u.wc.Close()
+	}
 }
 
-// sendMessage ...
+// sendMessage is the only writer of wc, it sends bytes in outbound queue until the
+// queue is closed.
 func (u *user) sendMessage() {
-	for bs := range u.writeChan {
-		u.wc.SetWriteDeadline(time.Now().Add(interval))
+	for {
+		bs, ok := u.out.pop()
+		if !ok {
+			// user is kicked, close connection after all messages are sent.
+			if u.out.isDrained() {
+				u.disconnect()
+			}
+			return
+		}
+
+		u.wc.SetWriteDeadline(time.Now().Add(b.WriteTimeout))
 		if err := ws.Message.Send(u.wc, bs); err != nil {
 			logger.Errorf("Can't send: %s \n", err)
 		}
@@ -235,37 +333,114 @@ func (u *user) sendMessage() {
 // play ...
 func (u *user) receiveAndUploadMessage() {
 	var cache []byte
+	if u.flood == nil {
+		u.flood = newFloodGuard()
+	}
+	timeout := u.heartbeatTimeout
+	if timeout <= 0 {
+		timeout = b.HeartbeatTimeout
+	}
+
+	// logs of messages from frontend carry uid and message type.
+	ulog := logger.With(log.Fields{"uid": u.uid})
+
+RECEIVEOVER:
 	for {
-		// receive bytes
-		u.wc.SetReadDeadline(time.Now().Add(interval))
+		// receive bytes, any message including heartbeat keeps connection alive.
+		u.wc.SetReadDeadline(time.Now().Add(timeout))
 		if err := ws.Message.Receive(u.wc, &cache); err != nil {
+			if err == ws.ErrFrameTooLarge {
+				ulog.Infof("Client Message Error: %v.\n", err)
+				// oversized frames count as flood like broken frames.
+				if u.flood.violate() == floodDisconnect {
+					ulog.Warnf("User %d floods server, disconnect it. \n", u.uid)
+					break
+				}
+				u.sendError(
+					constructErrorStringForMsg(nil, m.ErrMessageTooLarge.Error()))
+				continue
+			}
 			if err != io.EOF {
 				logger.Errorf("Websocket Message Receive Error: %s \n", err)
 			}
 			break
 		}
 
-		// convert bytes to infopkg
-		ipkg, msg, err := u.convertBytesToInfopkg(cache)
+		// convert bytes to message
+		msg, err := m.NewMessageFromBytes(cache)
+		if err != nil {
+			ulog.Infof("Client Message Error: %v.\n", err)
+			// broken frames count as flood, because each of them costs a reply.
+			if u.flood.violate() == floodDisconnect {
+				ulog.Warnf("User %d floods server, disconnect it. \n", u.uid)
+				break
+			}
+			u.sendError(
+				constructErrorStringForMsg(nil, m.ErrInvalidMessage.Error()))
+			continue
+		}
+
+		mlog := ulog.With(log.Fields{"type": msg.Type()})
+		mlog.Debugf("Receive message of %d bytes. \n", len(cache))
+
+		// flood protection, message is checked before unmarshaling its body.
+		u.flood.setTick(u.tickDuration())
+		switch u.flood.check(msg.Type()) {
+		case floodDrop:
+			continue
+		case floodWarn:
+			mlog.Warnf("User %d sends messages too frequently. \n", u.uid)
+			u.sendError(constructErrorStringForMsg(msg, errTooFrequent.Error()))
+			continue
+		case floodDisconnect:
+			mlog.Warnf("User %d floods server, disconnect it. \n", u.uid)
+			break RECEIVEOVER
+		}
+
+		// convert message to infopkg
+		ipkg, err := m.NewInfoPkgFromMsg(msg)
 		if err != nil {
 			if err != m.ErrEmptyInfo {
-				logger.Infof("Client Message Error: %v.\n", err)
+				mlog.Infof("Client Message Error: %v.\n", err)
 				u.sendError(
 					constructErrorStringForMsg(msg, m.ErrInvalidMessage.Error()))
 			}
 			continue
 		}
 
+		// pong and heartbeat are handled by user itself, never uploaded to room.
+		if po, ok := ipkg.(*m.PongInfo); ok {
+			u.handlePong(po, msg.Timestamp())
+			continue
+		}
+		if _, ok := ipkg.(*m.HeartbeatInfo); ok {
+			continue
+		}
+
+		// the playground perceived by user is older than now because of latency.
+		if pi, ok := ipkg.(*m.PlaygroundInfo); ok {
+			pi.Timestamp = u.perceivedTime(msg.Timestamp())
+		}
+
 		// pre operation for infopkg
 		if err := u.preOperationForIpkg(ipkg); err != nil {
-			logger.Infof("Client Message Error: %v.\n", err)
+			mlog.Infof("Client Message Error: %v.\n", err)
 			u.sendError(constructErrorStringForMsg(msg, err.Error()))
 			continue
 		}
 
-		// upload infopkg
-		if err := u.UploadInfo(ipkg); err != nil {
-			logger.Errorf("InfoChan of the user %d is nil.", u.ID())
+		// upload infopkg, a busy room is not the fault of user, so playground info is
+		// shed silently and other info is refused with an error.
+		err = u.UploadInfo(ipkg)
+		if err == errRoomBusy {
+			mlog.Infof("Room %d is busy, drop message of user %d. \n", u.Room(), u.uid)
+			if ipkg.Type() != m.InfoPlayground {
+				u.sendError(constructErrorStringForMsg(msg, errRoomBusy.Error()))
+			}
+			continue
+		}
+		if err != nil {
+			mlog.Errorf("InfoChan of the user %d is nil.", u.ID())
 			u.sendError(b.ErrServerError.Error())
 			break
 		}
@@ -274,11 +449,11 @@ func (u *user) receiveAndUploadMessage() {
 
 // overPlay ...
 func (u *user) overPlay() {
-	close(u.writeChan)
-
 	u.stateM.Lock()
 	defer u.stateM.Unlock()
 	u.state = 0
+
+	u.out.close()
 }
 
 // Play ...
@@ -290,8 +465,15 @@ func (u *user) Play() error {
 	u.state = 1
 	u.stateM.Unlock()
 
+	stop := make(chan struct{})
 	go u.sendMessage()
+	go u.keepPinging(stop)
 	u.receiveAndUploadMessage()
+	close(stop)
 	u.overPlay()
+
+	l := u.Latency()
+	logger.Infof("User %d is over, rtt %v, clock offset %v, %d samples. \n",
+		u.uid, l.RTT, l.Offset, l.Samples)
 	return nil
 }
@@ -1,6 +1,7 @@
 package user
 
 import (
+	b "barrage-server/base"
 	m "barrage-server/message"
 	tm "barrage-server/testLib/message"
 	"golang.org/x/net/websocket"
@@ -33,9 +34,9 @@ func testWebsocketClient(path string, testFunc func(ws *websocket.Conn)) {
 // TestUserBindRoomAndUpload ...
 func TestUserBindRoomAndUpload(t *testing.T) {
 	u := &user{uid: 99}
-	testchan := make(chan m.InfoPkg)
+	testchan := make(chan m.InfoPkg, 10)
 
-	u.BindRoom(20, testchan)
+	u.BindRoom(20, testchan, 0)
 	if uroom := u.Room(); uroom != 20 {
 		t.Errorf("Room id of user is wrong, hope %d, get %d.", 20, uroom)
 	}
@@ -51,6 +52,26 @@ func TestUserBindRoomAndUpload(t *testing.T) {
 	case <-time.After(time.Millisecond * 100):
 		t.Error("Conn't get infopkg from testchan.")
 	}
+
+	// playground info is dropped by a busy room at once, other info waits a while.
+	busychan := make(chan m.InfoPkg, 1)
+	u.BindRoom(20, busychan, 0)
+	if err := u.UploadInfo(testInfopkg); err != nil {
+		t.Error(err)
+	}
+	if err := u.UploadInfo(&m.PlaygroundInfo{}); err != errRoomBusy {
+		t.Errorf("Error of uploading to busy room is wrong, hope %v, get %v.", errRoomBusy, err)
+	}
+	go func() {
+		time.Sleep(b.InfoUploadTimeout / 2)
+		<-busychan
+	}()
+	if err := u.UploadInfo(testInfopkg); err != nil {
+		t.Errorf("Info should be uploaded after room is drained, get %v.", err)
+	}
+	if err := u.UploadInfo(testInfopkg); err != errRoomBusy {
+		t.Errorf("Error of uploading to busy room is wrong, hope %v, get %v.", errRoomBusy, err)
+	}
 }
 
 // TestUserGuardMethods ...
@@ -59,9 +80,9 @@ func TestUserGuardMethods(t *testing.T) {
 	w.Add(2)
 
 	serverCheckFunc := func(wc *websocket.Conn) {
-		testchan := make(chan m.InfoPkg)
+		testchan := make(chan m.InfoPkg, 10)
 		u := NewUser(wc, 20)
-		u.BindRoom(20, testchan)
+		u.BindRoom(20, testchan, 0)
 
 		// test Play
 		go func() {
@@ -163,13 +184,13 @@ func TestUserSendAndSendErrorAndPlay(t *testing.T) {
 	w.Add(2)
 
 	serverCheckFunc := func(wc *websocket.Conn) {
-		testchan := make(chan m.InfoPkg)
+		testchan := make(chan m.InfoPkg, 10)
 		u := &user{
-			uid:       20,
-			wc:        wc,
-			writeChan: make(chan []byte, 50),
+			uid: 20,
+			wc:  wc,
+			out: newOutQueue(b.OutboundQueueSize),
 		}
-		u.BindRoom(20, testchan)
+		u.BindRoom(20, testchan, 0)
 
 		pi := tm.GenerateTestPlaygroundInfo(0, 1, 1, 1, 1)
 		// test Send
@@ -266,13 +287,14 @@ func TestUserReceiveTimeout(t *testing.T) {
 	w.Add(2)
 
 	serverCheckFunc := func(wc *websocket.Conn) {
-		testchan := make(chan m.InfoPkg)
+		testchan := make(chan m.InfoPkg, 10)
 		u := &user{
-			uid:       20,
-			wc:        wc,
-			writeChan: make(chan []byte, 50),
+			uid:              20,
+			wc:               wc,
+			out:              newOutQueue(b.OutboundQueueSize),
+			heartbeatTimeout: time.Second * 2,
 		}
-		u.BindRoom(20, testchan)
+		u.BindRoom(20, testchan, 0)
 
 		// test Play
 		go func() {
@@ -308,3 +330,66 @@ func TestUserReceiveTimeout(t *testing.T) {
 
 	w.Wait()
 }
+
+// TestUserFrameTooLarge ...
+func TestUserFrameTooLarge(t *testing.T) {
+	var w sync.WaitGroup
+	w.Add(2)
+
+	serverCheckFunc := func(wc *websocket.Conn) {
+		wc.MaxPayloadBytes = 64
+		testchan := make(chan m.InfoPkg, 10)
+		u := NewUser(wc, 20)
+		u.BindRoom(20, testchan, 0)
+		go u.Play()
+
+		// user is still alive after discarding large frame.
+		select {
+		case ipkg := <-testchan:
+			if itype := ipkg.Type(); itype != m.InfoDisconnect {
+				t.Errorf("Receive Error info! hope %d, but get %d.", m.InfoDisconnect, itype)
+			}
+		case <-time.After(time.Second * 2):
+			t.Error("User should receive messages after large frame.")
+		}
+
+		w.Done()
+	}
+
+	clientCheckFunc := func(wc *websocket.Conn) {
+		wc.Write(make([]byte, 100))
+
+		var bs []byte
+		if err := websocket.Message.Receive(wc, &bs); err != nil {
+			t.Error(err)
+		}
+		msg, err := m.NewMessageFromBytes(bs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body, right := string(msg.Body()[1:]), m.ErrMessageTooLarge.Error(); !strings.Contains(body, right) {
+			t.Errorf("Wrong error message from server, hope contains '%s', get '%s'.", right, body)
+		}
+
+		di := &m.DisconnectInfo{RID: 20, UID: 20}
+		msg, _ = m.NewMessageFromInfoPkg(di)
+		bs, _ = msg.MarshalBinary()
+		wc.Write(bs)
+		time.Sleep(time.Millisecond * 100)
+		wc.WriteClose(0)
+
+		w.Done()
+	}
+
+	go func() {
+		createTestWebsocket("2344", serverCheckFunc)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	go func() {
+		testWebsocketClient("2344", clientCheckFunc)
+	}()
+
+	w.Wait()
+}